@@ -1,39 +1,210 @@
-package config
-
-import (
-	"os"
-)
-
-type Config struct {
-	MongoURI      string
-	JWTSecret     string
-	OpenAIAPIKey  string
-	DatabaseName  string
-	Port          string
-}
-
-var AppConfig *Config
-
-func LoadConfig() {
-	AppConfig = &Config{
-		MongoURI:     getEnv("MONGODB_URI", "mongodb://localhost:27017"),
-		JWTSecret:    getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-		OpenAIAPIKey: getEnv("OPENAI_API_KEY", ""),
-		DatabaseName: getEnv("DATABASE_NAME", "stream4you"),
-		Port:         getEnv("PORT", "8080"),
-	}
-}
-
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-func init() {
-	LoadConfig()
-}
-
-
-
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+type Config struct {
+	MongoURI      string
+	JWTSecret     string
+	OpenAIAPIKey  string
+	DatabaseName  string
+	Port          string
+
+	// Movie import: uploads larger than this are processed in the background
+	ImportAsyncThreshold int64
+	ImportMaxSize        int64
+
+	// Deleted movies stay in the trash this long before they are purged
+	TrashRetentionDays int64
+	TrashPurgeInterval int64 // minutes
+
+	// How often scheduled movies are published and expired ones taken down
+	PublishCheckInterval int64 // seconds
+
+	// Media processing
+	FFmpegPath   string
+	ImageMaxSize int64
+
+	// ffprobe reads duration, codecs and streams of uploaded and linked
+	// videos
+	FFprobePath string
+
+	// Adaptive streaming: parallel transcode jobs (0 disables packaging)
+	// and how long a single job may run
+	TranscodeWorkers int64
+	TranscodeTimeout int64 // minutes
+
+	// Largest video accepted by the resumable upload endpoint and how long
+	// unfinished uploads are kept after their last chunk (0 keeps them)
+	UploadMaxSize int64
+	UploadExpiry  int64 // hours
+
+	// Where videos and images are kept: "local" (the uploads directory) or
+	// "s3" for an S3-compatible service such as MinIO
+	StorageBackend string
+	S3Endpoint     string
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3PathStyle    bool
+	PresignExpiry  int64 // seconds
+
+	// Whether /url also returns a presigned link to the raw video. Such a
+	// link bypasses signed stream URLs and stream limits until it expires.
+	DirectMediaURLs bool
+
+	// Signed stream URLs: how long they stay valid and whether they only
+	// work from the IP they were requested from. Without a secret, one is
+	// derived from the JWT secret.
+	StreamURLSecret string
+	StreamURLExpiry int64 // seconds
+	StreamURLBindIP bool
+
+	// Reverse proxies whose X-Forwarded-For header is believed, as IPs or
+	// CIDRs. Without any, the client IP is the address of the connection.
+	TrustedProxies []string
+
+	// Percentage of a movie after which it counts as watched
+	WatchedThreshold int64
+
+	// Simultaneous streams per user, by plan for users with one. Playback
+	// sessions without a heartbeat for the timeout no longer count.
+	MaxStreams             int64
+	PlanStreamLimits       map[string]int64
+	PlaybackSessionTimeout int64 // seconds
+
+	// Player events are deleted after this many days
+	AnalyticsRetentionDays int64
+
+	// Directories local media may be served from. Uploads go to the first.
+	MediaRoots []string
+
+	// Language of the base movie fields and the languages translations
+	// may be added for
+	DefaultLanguage    string
+	SupportedLanguages []string
+}
+
+var AppConfig *Config
+
+func LoadConfig() {
+	AppConfig = &Config{
+		MongoURI:     getEnv("MONGODB_URI", "mongodb://localhost:27017"),
+		JWTSecret:    getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+		OpenAIAPIKey: getEnv("OPENAI_API_KEY", ""),
+		DatabaseName: getEnv("DATABASE_NAME", "stream4you"),
+		Port:         getEnv("PORT", "8080"),
+
+		ImportAsyncThreshold: getEnvInt64("IMPORT_ASYNC_THRESHOLD", 1<<20),
+		ImportMaxSize:        getEnvInt64("IMPORT_MAX_SIZE", 100<<20),
+
+		TrashRetentionDays: getEnvInt64("TRASH_RETENTION_DAYS", 30),
+		TrashPurgeInterval: getEnvInt64("TRASH_PURGE_INTERVAL", 60),
+
+		PublishCheckInterval: getEnvInt64("PUBLISH_CHECK_INTERVAL", 60),
+
+		FFmpegPath:   getEnv("FFMPEG_PATH", "ffmpeg"),
+		ImageMaxSize: getEnvInt64("IMAGE_MAX_SIZE", 10<<20),
+
+		FFprobePath: getEnv("FFPROBE_PATH", "ffprobe"),
+
+		TranscodeWorkers: getEnvInt64("TRANSCODE_WORKERS", 1),
+		TranscodeTimeout: getEnvInt64("TRANSCODE_TIMEOUT", 360),
+
+		UploadMaxSize: getEnvInt64("UPLOAD_MAX_SIZE", 20<<30),
+		UploadExpiry:  getEnvInt64("UPLOAD_EXPIRY", 24),
+
+		StorageBackend: getEnv("STORAGE_BACKEND", "local"),
+		S3Endpoint:     getEnv("S3_ENDPOINT", ""),
+		S3Region:       getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:       getEnv("S3_BUCKET", ""),
+		S3AccessKey:    getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:    getEnv("S3_SECRET_KEY", ""),
+		S3PathStyle:    getEnvBool("S3_PATH_STYLE", true),
+		PresignExpiry:  getEnvInt64("PRESIGN_EXPIRY", 900),
+
+		DirectMediaURLs: getEnvBool("DIRECT_MEDIA_URLS", false),
+
+		StreamURLSecret: getEnv("STREAM_URL_SECRET", ""),
+		StreamURLExpiry: getEnvInt64("STREAM_URL_EXPIRY", 6*60*60),
+		StreamURLBindIP: getEnvBool("STREAM_URL_BIND_IP", false),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES", ""),
+
+		WatchedThreshold: getEnvInt64("WATCHED_THRESHOLD", 90),
+
+		MaxStreams:             getEnvInt64("MAX_STREAMS", 2),
+		PlanStreamLimits:       getEnvInt64Map("PLAN_STREAM_LIMITS", ""),
+		PlaybackSessionTimeout: getEnvInt64("PLAYBACK_SESSION_TIMEOUT", 120),
+
+		AnalyticsRetentionDays: getEnvInt64("ANALYTICS_RETENTION_DAYS", 90),
+
+		MediaRoots: getEnvList("MEDIA_ROOTS", "uploads"),
+
+		DefaultLanguage:    getEnv("DEFAULT_LANGUAGE", "de"),
+		SupportedLanguages: getEnvList("SUPPORTED_LANGUAGES", "de,en"),
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList reads a comma separated list, dropping empty entries.
+func getEnvList(key, defaultValue string) []string {
+	values := []string{}
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvInt64Map reads comma separated key:value pairs such as
+// "basic:1,premium:4", dropping entries that are not numbers.
+func getEnvInt64Map(key, defaultValue string) map[string]int64 {
+	values := map[string]int64{}
+	for _, entry := range getEnvList(key, defaultValue) {
+		name, value, ok := strings.Cut(entry, ":")
+		if !ok {
+			continue
+		}
+		if parsed, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			values[strings.TrimSpace(name)] = parsed
+		}
+	}
+	return values
+}
+
+func init() {
+	LoadConfig()
+}
+
+
+
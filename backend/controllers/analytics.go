@@ -0,0 +1,299 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stream4you/backend/config"
+	"stream4you/backend/database"
+	"stream4you/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const playbackEventCollectionName = "playbackEvents"
+
+var playbackEventCollection = database.DB.Collection(playbackEventCollectionName)
+
+// Client clocks are trusted within this window, other events get the time
+// they arrive
+const maxEventClockSkew = 24 * time.Hour
+
+// StartAnalytics creates the time-series collection for player events.
+// Servers before MongoDB 5.0 have no time-series collections and get a
+// plain collection with a TTL index instead.
+func StartAnalytics() {
+	ctx := context.Background()
+	retention := config.AppConfig.AnalyticsRetentionDays * 24 * 60 * 60
+
+	opts := options.CreateCollection().
+		SetTimeSeriesOptions(options.TimeSeries().SetTimeField("time").SetMetaField("meta").SetGranularity("seconds")).
+		SetExpireAfterSeconds(retention)
+	err := database.DB.CreateCollection(ctx, playbackEventCollectionName, opts)
+	var commandErr mongo.CommandError
+	switch {
+	case err == nil:
+	case errors.As(err, &commandErr) && commandErr.Code == 48: // NamespaceExists
+	default:
+		log.Printf("analytics: no time-series collection (%v), using a TTL index", err)
+		_, err := playbackEventCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "time", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(retention)),
+		})
+		if err != nil {
+			log.Printf("analytics: failed to create TTL index: %v", err)
+		}
+	}
+
+	_, err = playbackEventCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "meta.movieId", Value: 1}, {Key: "time", Value: 1}},
+	})
+	if err != nil {
+		log.Printf("analytics: failed to create movie index: %v", err)
+	}
+}
+
+// RecordPlaybackEvents stores a batch of player events. It is part of the
+// signed stream URL, so players can send the last batch with
+// navigator.sendBeacon when the page is closed.
+func RecordPlaybackEvents(c *gin.Context) {
+	var req models.PlaybackEventsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	movieID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+	userID, _ := primitive.ObjectIDFromHex(c.GetString("userId"))
+	sessionID, _ := primitive.ObjectIDFromHex(c.GetString("playbackSessionId"))
+	meta := models.PlaybackEventMeta{MovieID: movieID, UserID: userID, SessionID: sessionID}
+
+	now := time.Now()
+	documents := make([]interface{}, 0, len(req.Events))
+	for _, input := range req.Events {
+		at := now
+		if input.Time != nil && input.Time.Before(now) && now.Sub(*input.Time) < maxEventClockSkew {
+			at = *input.Time
+		}
+		documents = append(documents, models.PlaybackEvent{
+			Time:     at,
+			Meta:     meta,
+			Type:     input.Type,
+			Position: input.Position,
+			Played:   input.Played,
+			Duration: input.Duration,
+			From:     input.From,
+			Bitrate:  input.Bitrate,
+			Error:    input.Error,
+		})
+	}
+
+	if _, err := playbackEventCollection.InsertMany(context.Background(), documents); err != nil {
+		log.Printf("analytics: failed to store %d events: %v", len(documents), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store events"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"accepted": len(documents)})
+}
+
+// analyticsWindow reads the from and to parameters (RFC 3339 or
+// YYYY-MM-DD), by default the last 30 days, and an optional movieId.
+func analyticsWindow(c *gin.Context) (bson.M, time.Time, time.Time, error) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+	for _, param := range []struct {
+		name   string
+		target *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		name, target := param.name, param.target
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			parsed, err = time.Parse("2006-01-02", value)
+		}
+		if err != nil {
+			return nil, from, to, errors.New(name + " must be a date such as 2024-05-01")
+		}
+		*target = parsed
+	}
+	if !from.Before(to) {
+		return nil, from, to, errors.New("from must be before to")
+	}
+
+	match := bson.M{"time": bson.M{"$gte": from, "$lt": to}}
+	if movieID := c.Query("movieId"); movieID != "" {
+		objectID, err := primitive.ObjectIDFromHex(movieID)
+		if err != nil {
+			return nil, from, to, errors.New("movieId must be a movie ID")
+		}
+		match["meta.movieId"] = objectID
+	}
+	return match, from, to, nil
+}
+
+// playbackSessionStages reduce the events in match to one document per
+// playback session that started in the window, with whether it completed,
+// how long it played and how long it stalled.
+func playbackSessionStages(match bson.M) mongo.Pipeline {
+	isType := func(eventType string) bson.M {
+		return bson.M{"$eq": bson.A{"$type", eventType}}
+	}
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       bson.M{"movieId": "$meta.movieId", "sessionId": "$meta.sessionId"},
+			"startedAt": bson.M{"$min": "$time"},
+			"started":   bson.M{"$max": bson.M{"$cond": bson.A{isType(models.PlaybackEventStart), 1, 0}}},
+			"completed": bson.M{"$max": bson.M{"$cond": bson.A{isType(models.PlaybackEventComplete), 1, 0}}},
+			"watchTime": bson.M{"$max": "$played"},
+			"stalled":   bson.M{"$sum": bson.M{"$cond": bson.A{isType(models.PlaybackEventBuffer), "$duration", 0}}},
+		}}},
+		{{Key: "$match", Value: bson.M{"started": 1}}},
+	}
+}
+
+// GetDailyPlays counts plays per movie and day. A play is a playback
+// session with a start event. Days are in the time zone tz (default UTC).
+func GetDailyPlays(c *gin.Context) {
+	match, from, to, err := analyticsWindow(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tz := c.DefaultQuery("tz", "UTC")
+	if _, err := time.LoadLocation(tz); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tz must be a time zone such as Europe/Berlin"})
+		return
+	}
+
+	pipeline := append(playbackSessionStages(match),
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"movieId": "$_id.movieId",
+				"day":     bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$startedAt", "timezone": tz}},
+			},
+			"plays": bson.M{"$sum": 1},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id.day", Value: 1}, {Key: "plays", Value: -1}}}},
+	)
+	cursor, err := playbackEventCollection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate events"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var rows []struct {
+		ID struct {
+			MovieID primitive.ObjectID `bson:"movieId"`
+			Day     string             `bson:"day"`
+		} `bson:"_id"`
+		Plays int `bson:"plays"`
+	}
+	if err := cursor.All(context.Background(), &rows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode events"})
+		return
+	}
+
+	items := []gin.H{}
+	for _, row := range rows {
+		items = append(items, gin.H{"day": row.ID.Day, "movieId": row.ID.MovieID, "plays": row.Plays})
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "from": from, "to": to, "tz": tz})
+}
+
+// GetMovieAnalytics returns per movie the plays, the share of them that
+// completed, the average watch time and the rebuffer ratio: the time spent
+// stalled relative to the time spent playing and stalled. Movies with the
+// most plays come first.
+func GetMovieAnalytics(c *gin.Context) {
+	match, from, to, err := analyticsWindow(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	pipeline := append(playbackSessionStages(match),
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":         "$_id.movieId",
+			"plays":       bson.M{"$sum": 1},
+			"completions": bson.M{"$sum": "$completed"},
+			"watchTime":   bson.M{"$sum": "$watchTime"},
+			"stalled":     bson.M{"$sum": "$stalled"},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "plays", Value: -1}, {Key: "_id", Value: 1}}}},
+		bson.D{{Key: "$limit", Value: limit}},
+	)
+	cursor, err := playbackEventCollection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate events"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var rows []struct {
+		MovieID     primitive.ObjectID `bson:"_id"`
+		Plays       int                `bson:"plays"`
+		Completions int                `bson:"completions"`
+		WatchTime   float64            `bson:"watchTime"`
+		Stalled     float64            `bson:"stalled"`
+	}
+	if err := cursor.All(context.Background(), &rows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode events"})
+		return
+	}
+
+	movieIDs := []primitive.ObjectID{}
+	for _, row := range rows {
+		movieIDs = append(movieIDs, row.MovieID)
+	}
+	titles := map[primitive.ObjectID]string{}
+	movieCursor, err := movieCollection.Find(context.Background(), bson.M{"_id": bson.M{"$in": movieIDs}},
+		options.Find().SetProjection(bson.M{"title": 1}))
+	if err == nil {
+		var movies []models.Movie
+		movieCursor.All(context.Background(), &movies)
+		for _, movie := range movies {
+			titles[movie.ID] = movie.Title
+		}
+	}
+
+	items := []gin.H{}
+	for _, row := range rows {
+		rebufferRatio := 0.0
+		if row.WatchTime+row.Stalled > 0 {
+			rebufferRatio = row.Stalled / (row.WatchTime + row.Stalled)
+		}
+		items = append(items, gin.H{
+			"movieId":          row.MovieID,
+			"title":            titles[row.MovieID],
+			"plays":            row.Plays,
+			"completions":      row.Completions,
+			"completionRate":   float64(row.Completions) / float64(row.Plays),
+			"averageWatchTime": row.WatchTime / float64(row.Plays),
+			"totalWatchTime":   row.WatchTime,
+			"rebufferTime":     row.Stalled,
+			"rebufferRatio":    rebufferRatio,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "from": from, "to": to})
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"stream4you/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func catalogProjection(itemType string) bson.M {
+	return bson.M{
+		"type":        bson.M{"$literal": itemType},
+		"title":       1,
+		"description": 1,
+		"genre":       1,
+		"year":        1,
+		"rating":      1,
+		"posterUrl":   1,
+		"createdAt":   1,
+	}
+}
+
+// GetCatalog lists movies and series together, newest first. It accepts the
+// same filter parameters as GetMovies, plus type=movie|series.
+func GetCatalog(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "12"))
+	skip := (page - 1) * limit
+
+	itemType := c.Query("type")
+	if itemType != "" && itemType != models.CatalogTypeMovie && itemType != models.CatalogTypeSeries {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type, use movie or series"})
+		return
+	}
+
+	seriesPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: catalogFilter(c)}},
+		{{Key: "$project", Value: catalogProjection(models.CatalogTypeSeries)}},
+	}
+
+	var collection *mongo.Collection
+	var pipeline mongo.Pipeline
+	switch itemType {
+	case models.CatalogTypeSeries:
+		collection = seriesCollection
+		pipeline = seriesPipeline
+	default:
+		collection = movieCollection
+		pipeline = mongo.Pipeline{
+			{{Key: "$match", Value: availableMovies(catalogFilter(c))}},
+			{{Key: "$project", Value: catalogProjection(models.CatalogTypeMovie)}},
+		}
+		if itemType == "" {
+			pipeline = append(pipeline, bson.D{{Key: "$unionWith", Value: bson.M{
+				"coll":     seriesCollection.Name(),
+				"pipeline": seriesPipeline,
+			}}})
+		}
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.M{"createdAt": -1}}},
+		bson.D{{Key: "$facet", Value: bson.M{
+			"items": bson.A{bson.M{"$skip": skip}, bson.M{"$limit": limit}},
+			"total": bson.A{bson.M{"$count": "count"}},
+		}}},
+	)
+
+	cursor, err := collection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch catalog"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var results []struct {
+		Items []models.CatalogItem `bson:"items"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := cursor.All(context.Background(), &results); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode catalog"})
+		return
+	}
+
+	items := []models.CatalogItem{}
+	var total int64
+	if len(results) > 0 {
+		items = append(items, results[0].Items...)
+		if len(results[0].Total) > 0 {
+			total = results[0].Total[0].Count
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"stream4you/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxChapters = 200
+
+// Chapter titles that mark the intro and the credits when importing
+var (
+	introChapterPattern   = regexp.MustCompile(`(?i)^(intro|opening|vorspann)\b`)
+	creditsChapterPattern = regexp.MustCompile(`(?i)\b(credits|abspann|outro|ending)\b`)
+)
+
+// SetMovieChapters replaces a movie's chapters and markers. Chapters are
+// sorted by start; a chapter without end runs until the next one.
+func SetMovieChapters(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+
+	var req models.ChaptersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expected, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if expected == nil {
+		expected = req.Version
+	}
+
+	var current models.Movie
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": objectID})).Decode(&current)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+
+	duration := movieDurationSeconds(current)
+	chapters, errs := normalizeChapters(req.Chapters, duration)
+	errs = append(errs, validateMarkers(req.Markers, duration)...)
+	if len(errs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid chapters", "details": errs})
+		return
+	}
+
+	saveMovieAssets(c, objectID, current, bson.M{"chapters": chapters, "markers": markersOrNil(req.Markers)}, expected, gin.H{})
+}
+
+// ImportMovieChapters replaces a movie's chapters with the ones embedded in
+// its video, probing it if that has not happened yet. Markers that are not
+// set yet are taken from chapters titled like "Intro" or "Credits".
+func ImportMovieChapters(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+	expected, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var current models.Movie
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": objectID})).Decode(&current)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	if current.VideoURL == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Movie has no video"})
+		return
+	}
+
+	if current.MediaInfo == nil || current.MediaInfo.Source != current.VideoURL {
+		// Probing saves a new version, so the precondition is checked before
+		if expected != nil && *expected != current.Version {
+			respondMovieUpdateError(c, &versionConflictError{Current: current.Version}, true)
+			return
+		}
+		_, err := probeMovieVideo(objectID, current.VideoURL)
+		if errors.Is(err, exec.ErrNotFound) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "ffprobe is not available"})
+			return
+		}
+		// Probing may have set the duration, which is a new version the
+		// precondition now refers to
+		err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": objectID})).Decode(&current)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+		if expected != nil {
+			expected = &current.Version
+		}
+	}
+	info := current.MediaInfo
+	if info == nil || info.Source != current.VideoURL {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to probe the video"})
+		return
+	}
+	if info.Error != "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": info.Error})
+		return
+	}
+	if len(info.Chapters) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "The video has no embedded chapters"})
+		return
+	}
+
+	chapters, errs := normalizeChapters(info.Chapters, info.Duration)
+	if len(errs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid chapters in the video", "details": errs})
+		return
+	}
+	markers := markersFromChapters(chapters)
+	if current.Markers != nil {
+		if current.Markers.IntroStart != nil {
+			markers.IntroStart, markers.IntroEnd = current.Markers.IntroStart, current.Markers.IntroEnd
+		}
+		if current.Markers.CreditsStart != nil {
+			markers.CreditsStart = current.Markers.CreditsStart
+		}
+	}
+
+	saveMovieAssets(c, objectID, current, bson.M{"chapters": chapters, "markers": markersOrNil(markers)}, expected, gin.H{})
+}
+
+// movieDurationSeconds is the length of the movie's video as found by
+// probing or packaging, or 0 if it is not known.
+func movieDurationSeconds(movie models.Movie) float64 {
+	if movie.MediaInfo != nil && movie.MediaInfo.Source == movieVideoPath(movie) && movie.MediaInfo.Duration > 0 {
+		return movie.MediaInfo.Duration
+	}
+	if movie.Package != nil {
+		return movie.Package.Duration
+	}
+	return 0
+}
+
+// normalizeChapters sorts chapters by start, fills in missing ends and
+// checks that they neither overlap nor, with a known duration, run past
+// the end of the movie.
+func normalizeChapters(input []models.Chapter, duration float64) ([]models.Chapter, []string) {
+	errs := []string{}
+	if len(input) > maxChapters {
+		return nil, []string{fmt.Sprintf("a movie can have at most %d chapters", maxChapters)}
+	}
+
+	chapters := append([]models.Chapter{}, input...)
+	sort.SliceStable(chapters, func(i, j int) bool { return chapters[i].Start < chapters[j].Start })
+	for i := range chapters {
+		chapter := &chapters[i]
+		chapter.Title = strings.TrimSpace(chapter.Title)
+		if chapter.Title == "" {
+			errs = append(errs, fmt.Sprintf("chapter at %.3fs: title is required", chapter.Start))
+		}
+		if chapter.Start < 0 || (duration > 0 && chapter.Start >= duration) {
+			errs = append(errs, fmt.Sprintf("chapter %q: start is outside the movie", chapter.Title))
+			continue
+		}
+		if i+1 < len(chapters) {
+			next := chapters[i+1].Start
+			if next == chapter.Start {
+				errs = append(errs, fmt.Sprintf("chapter %q: another chapter starts at the same time", chapter.Title))
+				continue
+			}
+			if chapter.End == 0 {
+				chapter.End = next
+			}
+			if chapter.End > next {
+				errs = append(errs, fmt.Sprintf("chapter %q: overlaps the next chapter", chapter.Title))
+			}
+		}
+		if chapter.End != 0 && chapter.End <= chapter.Start {
+			errs = append(errs, fmt.Sprintf("chapter %q: end must be after start", chapter.Title))
+		}
+		// Container chapters often end a few frames after the last one
+		if duration > 0 && chapter.End > duration {
+			chapter.End = duration
+		}
+	}
+	return chapters, errs
+}
+
+func validateMarkers(markers models.PlaybackMarkers, duration float64) []string {
+	errs := []string{}
+	for name, value := range map[string]*float64{
+		"introStart": markers.IntroStart, "introEnd": markers.IntroEnd, "creditsStart": markers.CreditsStart,
+	} {
+		if value != nil && (*value < 0 || (duration > 0 && *value > duration)) {
+			errs = append(errs, name+" is outside the movie")
+		}
+	}
+	if (markers.IntroStart == nil) != (markers.IntroEnd == nil) {
+		errs = append(errs, "introStart and introEnd must be set together")
+	} else if markers.IntroStart != nil && *markers.IntroEnd <= *markers.IntroStart {
+		errs = append(errs, "introEnd must be after introStart")
+	}
+	if markers.IntroEnd != nil && markers.CreditsStart != nil && *markers.CreditsStart < *markers.IntroEnd {
+		errs = append(errs, "creditsStart must be after the intro")
+	}
+	sort.Strings(errs)
+	return errs
+}
+
+// markersFromChapters takes the intro and credits markers from chapters
+// titled accordingly. The credits are the last such chapter.
+func markersFromChapters(chapters []models.Chapter) models.PlaybackMarkers {
+	markers := models.PlaybackMarkers{}
+	for _, chapter := range chapters {
+		chapter := chapter
+		if markers.IntroStart == nil && chapter.End > 0 && introChapterPattern.MatchString(chapter.Title) {
+			markers.IntroStart, markers.IntroEnd = &chapter.Start, &chapter.End
+		}
+		if creditsChapterPattern.MatchString(chapter.Title) && (markers.IntroEnd == nil || chapter.Start >= *markers.IntroEnd) {
+			markers.CreditsStart = &chapter.Start
+		}
+	}
+	return markers
+}
+
+// markersOrNil returns nil for markers without any value, which removes
+// them from the movie.
+func markersOrNil(markers models.PlaybackMarkers) *models.PlaybackMarkers {
+	if markers.IntroStart == nil && markers.IntroEnd == nil && markers.CreditsStart == nil {
+		return nil
+	}
+	return &markers
+}
+
+// movieCreditsStart is the movie's credits marker, or 0 without one.
+func movieCreditsStart(movie models.Movie) float64 {
+	if movie.Markers == nil || movie.Markers.CreditsStart == nil {
+		return 0
+	}
+	return *movie.Markers.CreditsStart
+}
@@ -0,0 +1,360 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"stream4you/backend/database"
+	"stream4you/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var collectionCollection = database.DB.Collection("collections")
+
+// collectionSorts are the orders a smart collection can use.
+var collectionSorts = map[string]bson.D{
+	"":       {{Key: "createdAt", Value: -1}},
+	"newest": {{Key: "createdAt", Value: -1}},
+	"rating": {{Key: "rating", Value: -1}, {Key: "createdAt", Value: -1}},
+	"year":   {{Key: "year", Value: -1}, {Key: "title", Value: 1}},
+	"title":  {{Key: "title", Value: 1}},
+}
+
+// validateCollectionRequest checks a collection request and returns the
+// parsed movie IDs of a curated collection.
+func validateCollectionRequest(req models.CollectionRequest) ([]primitive.ObjectID, []string) {
+	errs := []string{}
+	if strings.TrimSpace(req.Title) == "" {
+		errs = append(errs, "title must not be empty")
+	}
+
+	if req.Type == models.CollectionTypeSmart {
+		if len(req.MovieIDs) > 0 {
+			errs = append(errs, "smart collections cannot list movies")
+		}
+		if req.Rule == nil {
+			return nil, append(errs, "smart collections need a rule")
+		}
+		if _, ok := collectionSorts[req.Rule.Sort]; !ok {
+			errs = append(errs, fmt.Sprintf("unknown sort %q", req.Rule.Sort))
+		}
+		if req.Rule.Limit < 0 {
+			errs = append(errs, "limit must not be negative")
+		}
+		filter := req.Rule.Filter
+		if filter.YearFrom != 0 && filter.YearTo != 0 && filter.YearFrom > filter.YearTo {
+			errs = append(errs, "yearFrom must not be after yearTo")
+		}
+		if filter.MinRating < 0 || filter.MinRating > 5 {
+			errs = append(errs, "minRating must be between 0 and 5")
+		}
+		return nil, errs
+	}
+
+	if req.Rule != nil {
+		errs = append(errs, "curated collections cannot have a rule")
+	}
+	movieIDs := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, id := range req.MovieIDs {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid movie ID %q", id))
+			continue
+		}
+		if seen[objectID] {
+			errs = append(errs, fmt.Sprintf("movie %s is listed twice", id))
+			continue
+		}
+		seen[objectID] = true
+		movieIDs = append(movieIDs, objectID)
+	}
+	if len(errs) > 0 || len(movieIDs) == 0 {
+		return movieIDs, errs
+	}
+
+	count, err := movieCollection.CountDocuments(context.Background(), notDeleted(bson.M{"_id": bson.M{"$in": movieIDs}}))
+	if err != nil {
+		return movieIDs, append(errs, "failed to check movies")
+	}
+	if int(count) != len(movieIDs) {
+		errs = append(errs, "some movies do not exist")
+	}
+	return movieIDs, errs
+}
+
+// resolveCollectionMovies returns one page of the movies in a collection
+// and the total number of movies in it. Movies users cannot see right now
+// are skipped.
+func resolveCollectionMovies(collection models.Collection, skip, limit int) ([]models.Movie, int64, error) {
+	if collection.Type == models.CollectionTypeSmart && collection.Rule != nil {
+		rule := collection.Rule
+		filter := availableMovies(movieFilter(rule.Filter))
+		total, err := movieCollection.CountDocuments(context.Background(), filter)
+		if err != nil {
+			return nil, 0, err
+		}
+		if rule.Limit > 0 {
+			if total > int64(rule.Limit) {
+				total = int64(rule.Limit)
+			}
+			if skip+limit > rule.Limit {
+				limit = rule.Limit - skip
+			}
+		}
+		if limit <= 0 {
+			return []models.Movie{}, total, nil
+		}
+
+		opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(limit)).SetSort(collectionSorts[rule.Sort])
+		cursor, err := movieCollection.Find(context.Background(), filter, opts)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer cursor.Close(context.Background())
+
+		movies := []models.Movie{}
+		if err := cursor.All(context.Background(), &movies); err != nil {
+			return nil, 0, err
+		}
+		return movies, total, nil
+	}
+
+	movies := []models.Movie{}
+	if len(collection.MovieIDs) == 0 {
+		return movies, 0, nil
+	}
+
+	cursor, err := movieCollection.Find(context.Background(), availableMovies(bson.M{"_id": bson.M{"$in": collection.MovieIDs}}))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	var found []models.Movie
+	if err := cursor.All(context.Background(), &found); err != nil {
+		return nil, 0, err
+	}
+	byID := map[primitive.ObjectID]models.Movie{}
+	for _, movie := range found {
+		byID[movie.ID] = movie
+	}
+
+	// Keep the curated order
+	for _, id := range collection.MovieIDs {
+		if movie, ok := byID[id]; ok {
+			movies = append(movies, movie)
+		}
+	}
+	total := int64(len(movies))
+	if skip >= len(movies) {
+		return []models.Movie{}, total, nil
+	}
+	end := skip + limit
+	if end > len(movies) {
+		end = len(movies)
+	}
+	return movies[skip:end], total, nil
+}
+
+func GetCollections(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "12"))
+	skip := (page - 1) * limit
+
+	filter := bson.M{}
+	if collectionType := c.Query("type"); collectionType != "" {
+		filter["type"] = collectionType
+	}
+
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(limit)).SetSort(bson.M{"createdAt": -1})
+	cursor, err := collectionCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collections"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	collections := []models.Collection{}
+	if err := cursor.All(context.Background(), &collections); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode collections"})
+		return
+	}
+
+	total, _ := collectionCollection.CountDocuments(context.Background(), filter)
+
+	c.JSON(http.StatusOK, gin.H{
+		"collections": collections,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// GetCollection returns a collection with one page of its movies, in the
+// curated order or the order of the smart collection's rule.
+func GetCollection(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "12"))
+	if page < 1 {
+		page = 1
+	}
+	skip := (page - 1) * limit
+
+	var collection models.Collection
+	err = collectionCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&collection)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+
+	movies, total, err := resolveCollectionMovies(collection, skip, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"collection": collection,
+		"movies":     movies,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func CreateCollection(c *gin.Context) {
+	var req models.CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	movieIDs, errs := validateCollectionRequest(req)
+	if len(errs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid collection", "details": errs})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	collection := models.Collection{
+		ID:          primitive.NewObjectID(),
+		Title:       strings.TrimSpace(req.Title),
+		Description: req.Description,
+		ArtworkURL:  req.ArtworkURL,
+		Type:        req.Type,
+		MovieIDs:    movieIDs,
+		Rule:        req.Rule,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		CreatedBy:   userObjectID,
+	}
+
+	if _, err := collectionCollection.InsertOne(context.Background(), collection); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create collection"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+// UpdateCollection replaces a collection. For curated collections the
+// order of movieIds is the display order.
+func UpdateCollection(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+
+	var req models.CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	movieIDs, errs := validateCollectionRequest(req)
+	if len(errs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid collection", "details": errs})
+		return
+	}
+
+	set := bson.M{
+		"title":       strings.TrimSpace(req.Title),
+		"description": req.Description,
+		"artworkUrl":  req.ArtworkURL,
+		"type":        req.Type,
+		"updatedAt":   time.Now(),
+	}
+	unset := bson.M{}
+	if req.Type == models.CollectionTypeSmart {
+		set["rule"] = req.Rule
+		unset["movieIds"] = ""
+	} else {
+		set["movieIds"] = movieIDs
+		unset["rule"] = ""
+	}
+
+	var collection models.Collection
+	err = collectionCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": objectID},
+		bson.M{"$set": set, "$unset": unset},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&collection)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update collection"})
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+func DeleteCollection(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+
+	result, err := collectionCollection.DeleteOne(context.Background(), bson.M{"_id": objectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete collection"})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection deleted successfully"})
+}
@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"stream4you/backend/config"
+	"stream4you/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var errExtraNotFound = errors.New("extra not found")
+
+// AddMovieExtra adds a trailer or other extra to a movie. Its video is
+// either linked with videoUrl or uploaded afterwards with the extra's ID
+// as "extraId" in the upload metadata.
+func AddMovieExtra(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+
+	var req models.ExtraRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errs := validateMediaPath("videoUrl", req.VideoURL); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": strings.Join(errs, "; ")})
+		return
+	}
+	expected, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if expected == nil {
+		expected = req.Version
+	}
+
+	var current models.Movie
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": objectID})).Decode(&current)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+
+	extra := models.MovieExtra{
+		ID:        primitive.NewObjectID().Hex(),
+		Kind:      req.Kind,
+		Title:     strings.TrimSpace(req.Title),
+		VideoURL:  req.VideoURL,
+		CreatedAt: time.Now(),
+	}
+	extras := append(append([]models.MovieExtra{}, current.Extras...), extra)
+	saveMovieAssets(c, objectID, current, bson.M{"extras": extras}, expected, gin.H{"extra": extra})
+	if extra.VideoURL != "" {
+		go probeExtraVideo(objectID, extra.ID, extra.VideoURL)
+	}
+}
+
+// UpdateMovieExtra replaces an extra's kind, title and video. An empty
+// videoUrl keeps the current video.
+func UpdateMovieExtra(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+
+	var req models.ExtraRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errs := validateMediaPath("videoUrl", req.VideoURL); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": strings.Join(errs, "; ")})
+		return
+	}
+	expected, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if expected == nil {
+		expected = req.Version
+	}
+
+	var current models.Movie
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": objectID})).Decode(&current)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+
+	extras := append([]models.MovieExtra{}, current.Extras...)
+	index := findExtra(current, c.Param("extraId"))
+	if index < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Extra not found"})
+		return
+	}
+	extra := &extras[index]
+	extra.Kind = req.Kind
+	extra.Title = strings.TrimSpace(req.Title)
+	videoChanged := req.VideoURL != "" && req.VideoURL != extra.VideoURL
+	if videoChanged {
+		extra.VideoURL = req.VideoURL
+		extra.Duration = 0
+		extra.ContentType = ""
+	}
+
+	saveMovieAssets(c, objectID, current, bson.M{"extras": extras}, expected, gin.H{"extra": *extra})
+	if videoChanged {
+		go probeExtraVideo(objectID, extra.ID, extra.VideoURL)
+	}
+}
+
+func DeleteMovieExtra(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+	expected, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var current models.Movie
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": objectID})).Decode(&current)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+
+	extras := []models.MovieExtra{}
+	for _, existing := range current.Extras {
+		if existing.ID != c.Param("extraId") {
+			extras = append(extras, existing)
+		}
+	}
+	if len(extras) == len(current.Extras) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Extra not found"})
+		return
+	}
+
+	// Stored files are kept: revisions may still point to them
+	saveMovieAssets(c, objectID, current, bson.M{"extras": extras}, expected, gin.H{})
+}
+
+// findExtra returns the index of the movie's extra with the given ID, or
+// -1.
+func findExtra(movie models.Movie, extraID string) int {
+	for i, extra := range movie.Extras {
+		if extra.ID == extraID {
+			return i
+		}
+	}
+	return -1
+}
+
+// attachExtraVideo points an extra's videoUrl to an uploaded file. The
+// extras are read and written as a whole, so concurrent edits make it
+// start over instead of being overwritten.
+func attachExtraVideo(movieID primitive.ObjectID, extraID, videoKey string, userID primitive.ObjectID) error {
+	for attempt := 0; ; attempt++ {
+		var current models.Movie
+		err := movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": movieID})).Decode(&current)
+		if err == mongo.ErrNoDocuments {
+			return errMovieNotFound
+		}
+		if err != nil {
+			return err
+		}
+		index := findExtra(current, extraID)
+		if index < 0 {
+			return errExtraNotFound
+		}
+
+		extras := append([]models.MovieExtra{}, current.Extras...)
+		extras[index].VideoURL = videoKey
+		extras[index].Duration = 0
+		extras[index].ContentType = ""
+		_, _, err = applyMovieUpdate(movieID, movieUpdate{
+			Set:             bson.M{"extras": extras},
+			ExpectedVersion: &current.Version,
+			UserID:          userID,
+			Action:          models.RevisionActionUpdate,
+		})
+		var conflict *versionConflictError
+		if errors.As(err, &conflict) && attempt < 2 {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		go probeExtraVideo(movieID, extraID, videoKey)
+		return nil
+	}
+}
+
+// probeExtraVideo stores the duration and content type of an extra's
+// video, unless the extra got another video in between. Extras are not
+// packaged, so this is all the player needs.
+func probeExtraVideo(movieID primitive.ObjectID, extraID, videoPath string) {
+	probeSlots <- struct{}{}
+	defer func() { <-probeSlots }()
+	ctx, cancel := context.WithTimeout(context.Background(), mediaProbeTimeout)
+	defer cancel()
+
+	result, err := probeMediaFile(ctx, videoPath)
+	if errors.Is(err, exec.ErrNotFound) {
+		log.Printf("ffprobe not found at %q, videos are stored without media info", config.AppConfig.FFprobePath)
+		return
+	}
+	if err != nil {
+		log.Printf("movie %s: failed to probe extra %s: %v", movieID.Hex(), extraID, err)
+		return
+	}
+
+	_, err = movieCollection.UpdateOne(context.Background(),
+		notDeleted(bson.M{"_id": movieID, "extras": bson.M{"$elemMatch": bson.M{"id": extraID, "videoUrl": videoPath}}}),
+		bson.M{"$set": bson.M{
+			"extras.$.duration":    result.Duration,
+			"extras.$.contentType": result.ContentType,
+		}})
+	if err != nil {
+		log.Printf("movie %s: failed to store media info of extra %s: %v", movieID.Hex(), extraID, err)
+	}
+}
+
+// setExtraStreamURLs fills in the stream URL of every extra with a video.
+func setExtraStreamURLs(movie *models.Movie) {
+	for i := range movie.Extras {
+		if movie.Extras[i].VideoURL != "" {
+			movie.Extras[i].StreamURL = "/api/stream/" + movie.ID.Hex() + "/extras/" + movie.Extras[i].ID
+		}
+	}
+}
+
+// StreamExtra serves an extra's video. Unlike the movie itself it needs
+// no stream URL or playback session and is available before and after
+// the movie's availability window; only drafts stay hidden.
+func StreamExtra(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+
+	var movie models.Movie
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": objectID})).Decode(&movie)
+	if err != nil || (!isAdmin(c) && movie.Status == models.MovieStatusDraft) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	index := findExtra(movie, c.Param("extraId"))
+	if index < 0 || movie.Extras[index].VideoURL == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Extra not found"})
+		return
+	}
+
+	extra := movie.Extras[index]
+	c.Header("Cache-Control", "public, max-age=3600")
+	serveVideoFile(c, extra.VideoURL, extra.ContentType)
+}
@@ -0,0 +1,362 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"stream4you/backend/config"
+	"stream4you/backend/media"
+	"stream4you/backend/models"
+	"stream4you/backend/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// imageWidths are the variant widths generated for each kind of artwork.
+// Widths above the original's are replaced by the original width.
+var imageWidths = map[string][]int{
+	models.ImageKindPoster:    {185, 342, 780},
+	models.ImageKindBackdrop:  {300, 780, 1280, 1920},
+	models.ImageKindThumbnail: {160, 320},
+}
+
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+const (
+	imageURLPrefix = "/api/images/"
+	jpegQuality    = 85
+	webpQuality    = 80
+)
+
+var imageKeyPattern = regexp.MustCompile(`^[0-9a-f]{64}\.(jpg|webp)$`)
+
+var webpUnavailable sync.Once
+
+// imageObjectKey is the media store key of an image variant.
+func imageObjectKey(key string) string {
+	return "images/" + key
+}
+
+// storeImageVariant stores data under its content hash. Identical variants
+// are only stored once.
+func storeImageVariant(data []byte, ext string) (string, error) {
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:]) + "." + ext
+	if storage.Exists(context.Background(), mediaStore, imageObjectKey(key)) {
+		return key, nil
+	}
+	return key, mediaStore.Put(context.Background(), imageObjectKey(key), bytes.NewReader(data))
+}
+
+// processImage renders the JPEG and WebP variants of an uploaded image.
+// WebP variants are skipped when ffmpeg is not installed.
+func processImage(ctx context.Context, kind string, img image.Image) ([]models.ImageVariant, error) {
+	original := img.Bounds().Dx()
+	variants := []models.ImageVariant{}
+	done := map[int]bool{}
+
+	for _, width := range imageWidths[kind] {
+		if width > original {
+			width = original
+		}
+		if done[width] {
+			continue
+		}
+		done[width] = true
+
+		resized := media.Resize(img, width)
+		bounds := resized.Bounds()
+
+		data, err := media.EncodeJPEG(resized, jpegQuality)
+		if err != nil {
+			return nil, err
+		}
+		key, err := storeImageVariant(data, "jpg")
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, models.ImageVariant{
+			Width:  bounds.Dx(),
+			Height: bounds.Dy(),
+			Format: "jpeg",
+			Size:   int64(len(data)),
+			Key:    key,
+			URL:    imageURLPrefix + key,
+		})
+
+		data, err = media.EncodeWebP(ctx, config.AppConfig.FFmpegPath, resized, webpQuality)
+		if errors.Is(err, exec.ErrNotFound) {
+			webpUnavailable.Do(func() {
+				log.Printf("ffmpeg not found at %q, images are stored without WebP variants", config.AppConfig.FFmpegPath)
+			})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		key, err = storeImageVariant(data, "webp")
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, models.ImageVariant{
+			Width:  bounds.Dx(),
+			Height: bounds.Dy(),
+			Format: "webp",
+			Size:   int64(len(data)),
+			Key:    key,
+			URL:    imageURLPrefix + key,
+		})
+	}
+	return variants, nil
+}
+
+// largestJPEG returns the URL of the biggest JPEG variant, which is what
+// posterUrl points to for clients that do not know about variants.
+func largestJPEG(variants []models.ImageVariant) string {
+	url, width := "", 0
+	for _, variant := range variants {
+		if variant.Format == "jpeg" && variant.Width > width {
+			url, width = variant.URL, variant.Width
+		}
+	}
+	return url
+}
+
+func movieImageParams(c *gin.Context) (primitive.ObjectID, string, bool) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return objectID, "", false
+	}
+	kind := c.Param("kind")
+	if _, ok := imageWidths[kind]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image kind, use poster, backdrop or thumbnail"})
+		return objectID, "", false
+	}
+	return objectID, kind, true
+}
+
+// UploadMovieImage accepts a poster, backdrop or thumbnail as multipart
+// field "image", stores resized JPEG and WebP variants and attaches them to
+// the movie, replacing earlier artwork of the same kind.
+func UploadMovieImage(c *gin.Context) {
+	objectID, kind, ok := movieImageParams(c)
+	if !ok {
+		return
+	}
+
+	maxSize := config.AppConfig.ImageMaxSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+	header, err := c.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Image is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Image is required"})
+		return
+	}
+	if header.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Image is too large"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded image"})
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	file.Close()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded image"})
+		return
+	}
+
+	// Trust the content, not the client's content type
+	if !allowedImageTypes[http.DetectContentType(data)] {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": media.ErrUnsupportedImage.Error()})
+		return
+	}
+	img, err := media.DecodeImage(data)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	expected, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var current models.Movie
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": objectID})).Decode(&current)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+
+	variants, err := processImage(c.Request.Context(), kind, img)
+	if err != nil {
+		log.Printf("movie %s: failed to process %s: %v", objectID.Hex(), kind, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process image"})
+		return
+	}
+
+	uploaded := models.MovieImage{
+		Kind:       kind,
+		Width:      img.Bounds().Dx(),
+		Height:     img.Bounds().Dy(),
+		Variants:   variants,
+		UploadedAt: time.Now(),
+	}
+	images := []models.MovieImage{uploaded}
+	for _, existing := range current.Images {
+		if existing.Kind != kind {
+			images = append(images, existing)
+		}
+	}
+
+	set := bson.M{"images": images}
+	if kind == models.ImageKindPoster {
+		set["posterUrl"] = largestJPEG(variants)
+	}
+	saveMovieAssets(c, objectID, current, set, expected, gin.H{"image": uploaded})
+}
+
+func DeleteMovieImage(c *gin.Context) {
+	objectID, kind, ok := movieImageParams(c)
+	if !ok {
+		return
+	}
+
+	expected, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var current models.Movie
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": objectID})).Decode(&current)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+
+	images := []models.MovieImage{}
+	var removed *models.MovieImage
+	for i, existing := range current.Images {
+		if existing.Kind == kind {
+			removed = &current.Images[i]
+			continue
+		}
+		images = append(images, existing)
+	}
+	if removed == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+
+	// Stored files are kept: revisions may still point to them
+	set := bson.M{"images": images}
+	if kind == models.ImageKindPoster && strings.HasPrefix(current.PosterURL, imageURLPrefix) {
+		set["posterUrl"] = ""
+	}
+	saveMovieAssets(c, objectID, current, set, expected, gin.H{})
+}
+
+// saveMovieAssets writes the fields set by the image and subtitle endpoints
+// as a new movie revision. Without an If-Match header, the version read by
+// the handler guards against concurrent edits. It reports whether the
+// movie was saved.
+func saveMovieAssets(c *gin.Context, movieID primitive.ObjectID, current models.Movie, set bson.M, expected *int, response gin.H) bool {
+	viaIfMatch := expected != nil
+	if expected == nil {
+		expected = &current.Version
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	movie, changes, err := applyMovieUpdate(movieID, movieUpdate{
+		Set:             set,
+		ExpectedVersion: expected,
+		UserID:          userObjectID,
+		Action:          models.RevisionActionUpdate,
+	})
+	if err != nil {
+		respondMovieUpdateError(c, err, viaIfMatch)
+		return false
+	}
+
+	response["movie"] = movie
+	response["changedFields"] = changedFieldNames(changes)
+	setMovieETag(c, movie)
+	c.JSON(http.StatusOK, response)
+	return true
+}
+
+// ServeImage serves a stored image variant. Names are content hashes, so
+// the response can be cached forever.
+func ServeImage(c *gin.Context) {
+	key := c.Param("file")
+	if !imageKeyPattern.MatchString(key) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+
+	object, info, ok := openMedia(c, imageObjectKey(key), "Image not found")
+	if !ok {
+		return
+	}
+	defer object.Close()
+
+	contentType := "image/jpeg"
+	if strings.HasSuffix(key, ".webp") {
+		contentType = "image/webp"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", `"`+strings.TrimSuffix(key, filepath.Ext(key))+`"`)
+	http.ServeContent(c.Writer, c.Request, key, info.ModTime, object)
+}
+
+// removeUnusedImages deletes the stored variants of a purged movie that no
+// other movie uses.
+func removeUnusedImages(movie models.Movie) {
+	for _, movieImage := range movie.Images {
+		for _, variant := range movieImage.Variants {
+			count, err := movieCollection.CountDocuments(context.Background(), bson.M{
+				"_id":                 bson.M{"$ne": movie.ID},
+				"images.variants.key": variant.Key,
+			})
+			if err != nil || count > 0 {
+				continue
+			}
+			if err := mediaStore.Delete(context.Background(), imageObjectKey(variant.Key)); err != nil {
+				log.Printf("purge %s: failed to remove image %s: %v", movie.ID.Hex(), variant.Key, err)
+			}
+		}
+	}
+}
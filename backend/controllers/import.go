@@ -0,0 +1,739 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"stream4you/backend/config"
+	"stream4you/backend/database"
+	"stream4you/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var importJobCollection = database.DB.Collection("importJobs")
+
+const (
+	importFormatCSV   = "csv"
+	importFormatJSONL = "jsonl"
+
+	// CSV cells holding lists (genre, cast) separate their values with this
+	importListSeparator = "|"
+
+	// Background jobs only keep failed rows, and at most this many of them
+	maxStoredImportErrors = 1000
+)
+
+// importFieldTypes lists the movie fields that can be set through an import,
+// keyed by their JSON/BSON name.
+var importFieldTypes = map[string]string{
+	"externalId":  "string",
+	"title":       "string",
+	"description": "string",
+	"genre":       "list",
+	"year":        "int",
+	"duration":    "int",
+	"posterUrl":   "string",
+	"videoUrl":    "string",
+	"director":    "string",
+	"cast":        "list",
+}
+
+var exportColumns = []string{
+	"externalId", "id", "title", "description", "genre", "year", "duration",
+	"rating", "posterUrl", "videoUrl", "director", "cast", "createdAt", "updatedAt",
+}
+
+type importRecord map[string]interface{}
+
+// importRowError is a problem confined to one input row; the import continues
+// with the next row.
+type importRowError struct {
+	err error
+}
+
+func (e *importRowError) Error() string { return e.err.Error() }
+
+type importReader interface {
+	Next() (importRecord, error)
+}
+
+type csvImportReader struct {
+	reader *csv.Reader
+	header []string
+}
+
+func newCSVImportReader(r io.Reader) (*csvImportReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+	reader.FieldsPerRecord = len(header)
+	return &csvImportReader{reader: reader, header: header}, nil
+}
+
+func (r *csvImportReader) Next() (importRecord, error) {
+	values, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &importRowError{err: parseErr.Err}
+		}
+		return nil, err
+	}
+
+	record := importRecord{}
+	for i, column := range r.header {
+		record[column] = values[i]
+	}
+	return record, nil
+}
+
+type jsonlImportReader struct {
+	scanner *bufio.Scanner
+}
+
+func newJSONLImportReader(r io.Reader) *jsonlImportReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	return &jsonlImportReader{scanner: scanner}
+}
+
+func (r *jsonlImportReader) Next() (importRecord, error) {
+	for r.scanner.Scan() {
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		var record importRecord
+		if err := decoder.Decode(&record); err != nil {
+			return nil, &importRowError{err: fmt.Errorf("invalid JSON: %v", err)}
+		}
+		return record, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func newImportReader(format string, r io.Reader) (importReader, error) {
+	switch format {
+	case importFormatCSV:
+		return newCSVImportReader(r)
+	case importFormatJSONL:
+		return newJSONLImportReader(r), nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+func detectImportFormat(requested, fileName, contentType string) string {
+	switch strings.ToLower(requested) {
+	case "csv":
+		return importFormatCSV
+	case "jsonl", "ndjson":
+		return importFormatJSONL
+	}
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return importFormatCSV
+	case ".jsonl", ".ndjson":
+		return importFormatJSONL
+	}
+
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return importFormatCSV
+	case strings.Contains(contentType, "ndjson"), strings.Contains(contentType, "jsonl"):
+		return importFormatJSONL
+	}
+	return ""
+}
+
+type importOptions struct {
+	Mapping map[string]string
+	DryRun  bool
+	UserID  primitive.ObjectID
+	// OnlyFailures keeps just the failed rows in the report
+	OnlyFailures bool
+}
+
+// processImport validates every row and, unless DryRun is set, creates new
+// movies or updates the movie with the same external ID.
+func processImport(reader importReader, opts importOptions) (models.ImportSummary, []models.ImportRowResult, error) {
+	summary := models.ImportSummary{}
+	rows := []models.ImportRowResult{}
+	seen := map[string]int{}
+
+	for row := 1; ; row++ {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		var result models.ImportRowResult
+		var rowErr *importRowError
+		switch {
+		case errors.As(err, &rowErr):
+			result = models.ImportRowResult{Row: row, Action: "skip", Errors: []string{rowErr.Error()}}
+		case err != nil:
+			return summary, rows, err
+		default:
+			result = importRow(row, record, seen, opts)
+		}
+
+		summary.Total++
+		switch {
+		case len(result.Errors) > 0:
+			summary.Failed++
+		case result.Action == "create":
+			summary.Created++
+		case result.Action == "update":
+			summary.Updated++
+		}
+
+		if opts.OnlyFailures && len(result.Errors) == 0 {
+			continue
+		}
+		if opts.OnlyFailures && len(rows) >= maxStoredImportErrors {
+			continue
+		}
+		rows = append(rows, result)
+	}
+
+	return summary, rows, nil
+}
+
+func importRow(row int, record importRecord, seen map[string]int, opts importOptions) models.ImportRowResult {
+	result := models.ImportRowResult{Row: row, Action: "skip"}
+
+	set, errs := mapImportRecord(record, opts.Mapping)
+	externalID, _ := set["externalId"].(string)
+	result.ExternalID = externalID
+	result.Title, _ = set["title"].(string)
+
+	if externalID != "" {
+		if previous, ok := seen[externalID]; ok {
+			errs = append(errs, fmt.Sprintf("duplicate externalId (already used in row %d)", previous))
+		} else {
+			seen[externalID] = row
+		}
+	}
+
+	var existing models.Movie
+	exists := false
+	if externalID != "" {
+		err := movieCollection.FindOne(context.Background(), bson.M{"externalId": externalID}).Decode(&existing)
+		if err == nil && existing.DeletedAt != nil {
+			errs = append(errs, "a movie with this externalId is in the trash, restore or purge it first")
+		} else if err == nil {
+			exists = true
+		} else if err != mongo.ErrNoDocuments {
+			result.Errors = append(errs, "database error while looking up externalId")
+			return result
+		}
+	}
+
+	errs = append(errs, validateImportedMovie(set, exists)...)
+	if len(errs) > 0 {
+		result.Errors = errs
+		return result
+	}
+
+	if exists {
+		result.Action = "update"
+		result.MovieID = existing.ID.Hex()
+	} else {
+		result.Action = "create"
+	}
+	if opts.DryRun {
+		return result
+	}
+
+	if exists {
+		_, _, err := applyMovieUpdate(existing.ID, movieUpdate{
+			Set:    set,
+			UserID: opts.UserID,
+			Action: models.RevisionActionImport,
+		})
+		if err != nil {
+			result.Action = "skip"
+			result.Errors = []string{"failed to update movie"}
+		}
+		return result
+	}
+
+	movie, err := movieFromImport(set, opts.UserID)
+	if err == nil {
+		_, err = movieCollection.InsertOne(context.Background(), movie)
+	}
+	if err != nil {
+		result.Action = "skip"
+		result.Errors = []string{"failed to create movie"}
+		return result
+	}
+	recordMovieCreated(movie, opts.UserID)
+	if movie.VideoURL != "" {
+		go probeMovieVideo(movie.ID, movie.VideoURL)
+	}
+	result.MovieID = movie.ID.Hex()
+	return result
+}
+
+// mapImportRecord renames the record's columns according to mapping and
+// converts the values to the types of the corresponding movie fields. Columns
+// that are not mapped (or, without mapping, not movie fields) are ignored.
+func mapImportRecord(record importRecord, mapping map[string]string) (bson.M, []string) {
+	set := bson.M{}
+	errs := []string{}
+
+	for column, raw := range record {
+		field := column
+		if mapping != nil {
+			target, ok := mapping[column]
+			if !ok {
+				continue
+			}
+			field = target
+		}
+		fieldType, ok := importFieldTypes[field]
+		if !ok {
+			continue
+		}
+
+		value, err := convertImportValue(raw, fieldType)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", field, err))
+			continue
+		}
+		if value != nil {
+			set[field] = value
+		}
+	}
+
+	return set, errs
+}
+
+// convertImportValue returns nil for empty cells so they do not overwrite
+// existing values on update.
+func convertImportValue(raw interface{}, fieldType string) (interface{}, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	switch fieldType {
+	case "string":
+		switch v := raw.(type) {
+		case string:
+			if strings.TrimSpace(v) == "" {
+				return nil, nil
+			}
+			return strings.TrimSpace(v), nil
+		case json.Number:
+			return v.String(), nil
+		}
+		return nil, errors.New("expected a string")
+
+	case "int":
+		var text string
+		switch v := raw.(type) {
+		case string:
+			text = strings.TrimSpace(v)
+		case json.Number:
+			text = v.String()
+		default:
+			return nil, errors.New("expected a number")
+		}
+		if text == "" {
+			return nil, nil
+		}
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a whole number", text)
+		}
+		return n, nil
+
+	case "list":
+		items := []string{}
+		switch v := raw.(type) {
+		case string:
+			if strings.TrimSpace(v) == "" {
+				return nil, nil
+			}
+			for _, item := range strings.Split(v, importListSeparator) {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		case []interface{}:
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, errors.New("expected a list of strings")
+				}
+				if s = strings.TrimSpace(s); s != "" {
+					items = append(items, s)
+				}
+			}
+		default:
+			return nil, errors.New("expected a list")
+		}
+		return items, nil
+	}
+
+	return nil, fmt.Errorf("unsupported field type %q", fieldType)
+}
+
+func validateImportedMovie(set bson.M, isUpdate bool) []string {
+	errs := []string{}
+
+	if _, ok := set["title"]; !ok && !isUpdate {
+		errs = append(errs, "title is required")
+	}
+	year, hasYear := set["year"].(int)
+	if !hasYear && !isUpdate {
+		errs = append(errs, "year is required")
+	}
+	if hasYear && (year < 1888 || year > time.Now().Year()+10) {
+		errs = append(errs, fmt.Sprintf("year %d is out of range", year))
+	}
+	if duration, ok := set["duration"].(int); ok && duration < 0 {
+		errs = append(errs, "duration must not be negative")
+	}
+	if videoURL, ok := set["videoUrl"].(string); ok {
+		errs = append(errs, validateMediaPath("videoUrl", videoURL)...)
+	}
+
+	return errs
+}
+
+func movieFromImport(set bson.M, userID primitive.ObjectID) (models.Movie, error) {
+	var movie models.Movie
+	data, err := bson.Marshal(set)
+	if err != nil {
+		return movie, err
+	}
+	if err := bson.Unmarshal(data, &movie); err != nil {
+		return movie, err
+	}
+
+	movie.ID = primitive.NewObjectID()
+	movie.CreatedAt = time.Now()
+	movie.UpdatedAt = time.Now()
+	movie.CreatedBy = userID
+	movie.Version = 1
+	return movie, nil
+}
+
+func parseImportMapping(raw string) (map[string]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var mapping map[string]string
+	if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+		return nil, errors.New("mapping must be a JSON object of column to field")
+	}
+	for column, field := range mapping {
+		if _, ok := importFieldTypes[field]; !ok {
+			return nil, fmt.Errorf("column %q is mapped to unknown field %q", column, field)
+		}
+	}
+	return mapping, nil
+}
+
+// importParam reads an import option from the query string or, for
+// multipart uploads, from the form.
+func importParam(c *gin.Context, name string) string {
+	if value := c.Query(name); value != "" {
+		return value
+	}
+	return c.PostForm(name)
+}
+
+// ImportMovies accepts a CSV or JSON Lines file either as multipart field
+// "file" or as the raw request body. Query/form parameters: format, mapping,
+// dryRun and async.
+func ImportMovies(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userObjectID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var body io.Reader
+	var fileName string
+	var size int64
+	var contentType = c.ContentType()
+
+	if strings.HasPrefix(contentType, "multipart/") {
+		var header *multipart.FileHeader
+		header, err = c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		defer file.Close()
+
+		body = file
+		fileName = header.Filename
+		size = header.Size
+		contentType = header.Header.Get("Content-Type")
+	} else {
+		body = http.MaxBytesReader(c.Writer, c.Request.Body, config.AppConfig.ImportMaxSize)
+		size = c.Request.ContentLength
+	}
+
+	if size > config.AppConfig.ImportMaxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import file is too large"})
+		return
+	}
+
+	format := detectImportFormat(importParam(c, "format"), fileName, contentType)
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown import format, use csv or jsonl"})
+		return
+	}
+
+	mapping, err := parseImportMapping(importParam(c, "mapping"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := importOptions{
+		Mapping: mapping,
+		DryRun:  importParam(c, "dryRun") == "true",
+		UserID:  userObjectID,
+	}
+
+	async := importParam(c, "async") == "true" ||
+		size < 0 || size > config.AppConfig.ImportAsyncThreshold
+	if async {
+		startImportJob(c, body, format, fileName, opts)
+		return
+	}
+
+	reader, err := newImportReader(format, body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary, rows, err := processImport(reader, opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read import file: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dryRun":  opts.DryRun,
+		"summary": summary,
+		"rows":    rows,
+	})
+}
+
+// startImportJob spools the upload to a temporary file, since the request
+// body is gone once the handler returns, and processes it in the background.
+func startImportJob(c *gin.Context, body io.Reader, format, fileName string, opts importOptions) {
+	tmp, err := os.CreateTemp("", "movie-import-*")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store import file"})
+		return
+	}
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read import file"})
+		return
+	}
+	tmp.Close()
+
+	job := models.ImportJob{
+		ID:        primitive.NewObjectID(),
+		Status:    models.ImportStatusPending,
+		Format:    format,
+		FileName:  fileName,
+		DryRun:    opts.DryRun,
+		Mapping:   opts.Mapping,
+		Rows:      []models.ImportRowResult{},
+		CreatedBy: opts.UserID,
+		CreatedAt: time.Now(),
+	}
+	if _, err := importJobCollection.InsertOne(context.Background(), job); err != nil {
+		os.Remove(tmp.Name())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create import job"})
+		return
+	}
+
+	opts.OnlyFailures = true
+	go runImportJob(job.ID, tmp.Name(), format, opts)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"jobId":  job.ID.Hex(),
+		"status": job.Status,
+	})
+}
+
+func runImportJob(jobID primitive.ObjectID, path, format string, opts importOptions) {
+	defer os.Remove(path)
+
+	importJobCollection.UpdateOne(context.Background(), bson.M{"_id": jobID},
+		bson.M{"$set": bson.M{"status": models.ImportStatusRunning}})
+
+	finish := func(update bson.M) {
+		now := time.Now()
+		update["finishedAt"] = now
+		if _, err := importJobCollection.UpdateOne(context.Background(), bson.M{"_id": jobID}, bson.M{"$set": update}); err != nil {
+			log.Printf("import job %s: failed to store result: %v", jobID.Hex(), err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		finish(bson.M{"status": models.ImportStatusFailed, "error": "failed to open import file"})
+		return
+	}
+	defer file.Close()
+
+	reader, err := newImportReader(format, file)
+	if err != nil {
+		finish(bson.M{"status": models.ImportStatusFailed, "error": err.Error()})
+		return
+	}
+
+	summary, rows, err := processImport(reader, opts)
+	update := bson.M{"status": models.ImportStatusCompleted, "summary": summary, "rows": rows}
+	if err != nil {
+		update["status"] = models.ImportStatusFailed
+		update["error"] = err.Error()
+	}
+	finish(update)
+}
+
+func GetImportJob(c *gin.Context) {
+	jobID, err := primitive.ObjectIDFromHex(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	var job models.ImportJob
+	if err := importJobCollection.FindOne(context.Background(), bson.M{"_id": jobID}).Decode(&job); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// ExportMovies streams the whole catalog as CSV or JSON Lines in the same
+// layout ImportMovies accepts.
+func ExportMovies(c *gin.Context) {
+	format := detectImportFormat(c.DefaultQuery("format", "jsonl"), "", "")
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown export format, use csv or jsonl"})
+		return
+	}
+
+	cursor, err := movieCollection.Find(context.Background(), notDeleted(bson.M{}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movies"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	fileName := "movies-" + time.Now().Format("20060102") + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	if format == importFormatCSV {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+	}
+	c.Status(http.StatusOK)
+
+	var csvWriter *csv.Writer
+	var jsonEncoder *json.Encoder
+	if format == importFormatCSV {
+		csvWriter = csv.NewWriter(c.Writer)
+		csvWriter.Write(exportColumns)
+	} else {
+		jsonEncoder = json.NewEncoder(c.Writer)
+	}
+
+	for count := 1; cursor.Next(context.Background()); count++ {
+		var movie models.Movie
+		if err := cursor.Decode(&movie); err != nil {
+			log.Printf("export: skipping undecodable movie: %v", err)
+			continue
+		}
+
+		if csvWriter != nil {
+			csvWriter.Write(movieExportRow(movie))
+		} else if err := jsonEncoder.Encode(movie); err != nil {
+			return
+		}
+
+		if count%100 == 0 {
+			if csvWriter != nil {
+				csvWriter.Flush()
+			}
+			c.Writer.Flush()
+		}
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+	}
+}
+
+func movieExportRow(movie models.Movie) []string {
+	return []string{
+		movie.ExternalID,
+		movie.ID.Hex(),
+		movie.Title,
+		movie.Description,
+		strings.Join(movie.Genre, importListSeparator),
+		strconv.Itoa(movie.Year),
+		strconv.Itoa(movie.Duration),
+		strconv.FormatFloat(movie.Rating, 'f', -1, 64),
+		movie.PosterURL,
+		movie.VideoURL,
+		movie.Director,
+		strings.Join(movie.Cast, importListSeparator),
+		movie.CreatedAt.Format(time.RFC3339),
+		movie.UpdatedAt.Format(time.RFC3339),
+	}
+}
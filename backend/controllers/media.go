@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"stream4you/backend/config"
+	"stream4you/backend/models"
+	"stream4you/backend/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// mediaStore holds all videos and images. Partial tus uploads stay in the
+// local uploads directory until they are complete.
+var mediaStore = newMediaStore()
+
+func newMediaStore() storage.MediaStore {
+	cfg := config.AppConfig
+	switch cfg.StorageBackend {
+	case "local":
+		return storage.NewLocalStore(cfg.MediaRoots...)
+	case "s3":
+		store, err := storage.NewS3Store(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3PathStyle)
+		if err != nil {
+			log.Fatal("Invalid S3 storage configuration: ", err)
+		}
+		return store
+	}
+	log.Fatalf("Unknown STORAGE_BACKEND %q, use local or s3", cfg.StorageBackend)
+	return nil
+}
+
+// mediaKey maps a stored media path such as a movie's videoUrl to its key
+// in the media store, see storage.MediaKey. Paths below "uploads" from
+// before the store existed are accepted even if it is no media root.
+func mediaKey(mediaPath string) (string, bool) {
+	return storage.MediaKey(mediaPath, config.AppConfig.MediaRoots, uploadsDir)
+}
+
+// Reasons a stored media path cannot be served
+const (
+	mediaPathInvalid     = "invalid"     // not a path inside the media roots
+	mediaPathOutsideRoot = "outsideRoot" // a symlink leads out of the root
+	mediaPathMissing     = "missing"
+)
+
+// checkMediaPath returns why mediaPath cannot be served, or "" if it can.
+func checkMediaPath(mediaPath string) string {
+	key, ok := mediaKey(mediaPath)
+	if !ok {
+		return mediaPathInvalid
+	}
+	_, err := mediaStore.Stat(context.Background(), key)
+	switch {
+	case errors.Is(err, storage.ErrOutsideRoot):
+		return mediaPathOutsideRoot
+	case errors.Is(err, storage.ErrNotFound):
+		return mediaPathMissing
+	case err != nil:
+		log.Printf("media %s: %v", key, err)
+	}
+	return ""
+}
+
+// validateMediaPath checks a video path before it is saved. Files that do
+// not exist yet are accepted, they may be uploaded afterwards.
+func validateMediaPath(field, mediaPath string) []string {
+	if mediaPath == "" {
+		return nil
+	}
+	switch checkMediaPath(mediaPath) {
+	case mediaPathInvalid:
+		return []string{field + " must be a path inside the media roots"}
+	case mediaPathOutsideRoot:
+		return []string{field + " resolves to a file outside the media roots"}
+	}
+	return nil
+}
+
+func videoContentType(key string) string {
+	if contentType := mime.TypeByExtension(path.Ext(key)); strings.HasPrefix(contentType, "video/") {
+		return contentType
+	}
+	return "video/mp4"
+}
+
+// openMedia opens a stored object for http.ServeContent. It responds
+// itself and returns false if the object cannot be opened.
+func openMedia(c *gin.Context, key, notFound string) (io.ReadSeekCloser, storage.Info, bool) {
+	info, err := mediaStore.Stat(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return nil, info, false
+	}
+	if errors.Is(err, storage.ErrOutsideRoot) {
+		log.Printf("media %s: refusing to serve, it resolves outside the media roots", key)
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return nil, info, false
+	}
+	if err != nil {
+		log.Printf("media %s: %v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open media file"})
+		return nil, info, false
+	}
+	return storage.NewReadSeeker(c.Request.Context(), mediaStore, key, info.Size), info, true
+}
+
+// readMedia reads a small stored object, such as a playlist, into memory.
+func readMedia(ctx context.Context, key string) ([]byte, error) {
+	object, err := mediaStore.OpenRange(ctx, key, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+	return io.ReadAll(object)
+}
+
+// serveMedia streams a stored object with support for range and
+// conditional requests.
+func serveMedia(c *gin.Context, key, contentType, notFound string) {
+	reader, info, ok := openMedia(c, key, notFound)
+	if !ok {
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", contentType)
+	http.ServeContent(c.Writer, c.Request, path.Base(key), info.ModTime, reader)
+}
+
+// presignMedia returns a direct download URL for key that is valid for
+// expires, if the store supports them.
+func presignMedia(key string, expires time.Duration) (string, time.Time, bool) {
+	url, err := mediaStore.Presign(context.Background(), key, expires)
+	if err != nil {
+		if !errors.Is(err, storage.ErrPresignUnsupported) {
+			log.Printf("media %s: failed to presign: %v", key, err)
+		}
+		return "", time.Time{}, false
+	}
+	return url, time.Now().Add(expires), true
+}
+
+// GetInvalidMediaPaths lists movies and episodes whose videoUrl cannot be
+// served because it is not inside the media roots. With
+// includeMissing=true, paths whose file does not exist are listed too.
+func GetInvalidMediaPaths(c *gin.Context) {
+	includeMissing := c.Query("includeMissing") == "true"
+	filter := bson.M{"videoUrl": bson.M{"$nin": bson.A{"", nil}}}
+	items := []gin.H{}
+
+	cursor, err := movieCollection.Find(context.Background(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movies"})
+		return
+	}
+	var movies []models.Movie
+	if err := cursor.All(context.Background(), &movies); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode movies"})
+		return
+	}
+	for _, movie := range movies {
+		reason := checkMediaPath(movie.VideoURL)
+		if reason == "" || (reason == mediaPathMissing && !includeMissing) {
+			continue
+		}
+		items = append(items, gin.H{
+			"type":     "movie",
+			"id":       movie.ID,
+			"title":    movie.Title,
+			"videoUrl": movie.VideoURL,
+			"reason":   reason,
+			"deleted":  movie.DeletedAt != nil,
+		})
+	}
+
+	cursor, err = episodeCollection.Find(context.Background(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episodes"})
+		return
+	}
+	var episodes []models.Episode
+	if err := cursor.All(context.Background(), &episodes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode episodes"})
+		return
+	}
+	for _, episode := range episodes {
+		reason := checkMediaPath(episode.VideoURL)
+		if reason == "" || (reason == mediaPathMissing && !includeMissing) {
+			continue
+		}
+		items = append(items, gin.H{
+			"type":     "episode",
+			"id":       episode.ID,
+			"seriesId": episode.SeriesID,
+			"title":    episode.Title,
+			"videoUrl": episode.VideoURL,
+			"reason":   reason,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items, "total": len(items)})
+}
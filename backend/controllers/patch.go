@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"stream4you/backend/models"
+	"stream4you/backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// movieRequestFromMovie returns the editable fields of movie in the shape a
+// PUT request would send them.
+func movieRequestFromMovie(movie models.Movie) models.UpdateMovieRequest {
+	req := models.UpdateMovieRequest{
+		ExternalID:  movie.ExternalID,
+		Title:       movie.Title,
+		Description: movie.Description,
+		Genre:       movie.Genre,
+		Year:        movie.Year,
+		Duration:    movie.Duration,
+		PosterURL:   movie.PosterURL,
+		VideoURL:    movie.VideoURL,
+		Director:    movie.Director,
+		Cast:        movie.Cast,
+	}
+	if req.Genre == nil {
+		req.Genre = []string{}
+	}
+	if req.Cast == nil {
+		req.Cast = []string{}
+	}
+	return req
+}
+
+// movieRequestSet turns a full movie request into the $set document for
+// applyMovieUpdate. Every editable field is included, so empty values clear
+// the stored ones.
+func movieRequestSet(req models.UpdateMovieRequest) bson.M {
+	genre, cast := req.Genre, req.Cast
+	if genre == nil {
+		genre = []string{}
+	}
+	if cast == nil {
+		cast = []string{}
+	}
+
+	return bson.M{
+		"externalId":  req.ExternalID,
+		"title":       strings.TrimSpace(req.Title),
+		"description": req.Description,
+		"genre":       genre,
+		"year":        req.Year,
+		"duration":    req.Duration,
+		"posterUrl":   req.PosterURL,
+		"videoUrl":    req.VideoURL,
+		"director":    req.Director,
+		"cast":        cast,
+	}
+}
+
+func validateMovieRequest(req models.UpdateMovieRequest) []string {
+	errs := []string{}
+
+	if strings.TrimSpace(req.Title) == "" {
+		errs = append(errs, "title must not be empty")
+	}
+	if req.Year < 1888 || req.Year > time.Now().Year()+10 {
+		errs = append(errs, fmt.Sprintf("year %d is out of range", req.Year))
+	}
+	if req.Duration < 0 {
+		errs = append(errs, "duration must not be negative")
+	}
+	for _, genre := range req.Genre {
+		if strings.TrimSpace(genre) == "" {
+			errs = append(errs, "genre must not contain empty entries")
+			break
+		}
+	}
+	for _, member := range req.Cast {
+		if strings.TrimSpace(member) == "" {
+			errs = append(errs, "cast must not contain empty entries")
+			break
+		}
+	}
+	errs = append(errs, validateMediaPath("videoUrl", req.VideoURL)...)
+
+	return errs
+}
+
+func isEditableMovieField(field string) bool {
+	for _, editable := range editableMovieFields {
+		if field == editable {
+			return true
+		}
+	}
+	return false
+}
+
+// PatchMovie applies a JSON merge patch (RFC 7396) or, with content type
+// application/json-patch+json, a JSON patch (RFC 6902) to the editable
+// fields of a movie. A null in a merge patch clears the field.
+func PatchMovie(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+
+	expected, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	contentType := c.ContentType()
+	if contentType != mergePatchContentType && contentType != jsonPatchContentType && contentType != "application/json" {
+		c.Header("Accept-Patch", mergePatchContentType+", "+jsonPatchContentType)
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Unsupported patch format"})
+		return
+	}
+
+	var movie models.Movie
+	if err := movieCollection.FindOne(c.Request.Context(), notDeleted(bson.M{"_id": objectID})).Decode(&movie); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+
+	document, err := toJSONDocument(movieRequestFromMovie(movie))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare movie"})
+		return
+	}
+
+	var patched interface{}
+	if contentType == jsonPatchContentType {
+		var operations []utils.JSONPatchOperation
+		if err := c.ShouldBindJSON(&operations); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON patch: " + err.Error()})
+			return
+		}
+		for _, op := range operations {
+			pointers := []string{op.Path}
+			if op.Op == "move" || op.Op == "copy" {
+				pointers = append(pointers, op.From)
+			}
+			for _, pointer := range pointers {
+				tokens, err := utils.ParseJSONPointer(pointer)
+				if err != nil || len(tokens) == 0 || !isEditableMovieField(tokens[0]) {
+					c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("Path %q does not point to an editable field", pointer)})
+					return
+				}
+			}
+		}
+		patched, err = utils.ApplyJSONPatch(document, operations)
+		if err != nil {
+			status := http.StatusUnprocessableEntity
+			if errors.Is(err, utils.ErrJSONPatchTestFailed) {
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		var patch map[string]interface{}
+		decoder := json.NewDecoder(c.Request.Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&patch); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Patch must be a JSON object"})
+			return
+		}
+		for field := range patch {
+			if !isEditableMovieField(field) {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("Field %q cannot be changed", field)})
+				return
+			}
+		}
+		patched = utils.MergePatch(document, patch)
+	}
+
+	// Decoding into the request type checks the field types; removed fields
+	// end up with their zero value.
+	data, err := json.Marshal(patched)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid patch result"})
+		return
+	}
+	var req models.UpdateMovieRequest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid field value: " + err.Error()})
+		return
+	}
+	if errs := validateMovieRequest(req); len(errs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": strings.Join(errs, "; ")})
+		return
+	}
+
+	if expected == nil {
+		// Still guard against changes made since we read the movie above
+		expected = &movie.Version
+	}
+	updated, changes, err := applyMovieUpdate(objectID, movieUpdate{
+		Set:             movieRequestSet(req),
+		ExpectedVersion: expected,
+		UserID:          userObjectID,
+		Action:          models.RevisionActionUpdate,
+	})
+	if err != nil {
+		respondMovieUpdateError(c, err, c.GetHeader("If-Match") != "")
+		return
+	}
+
+	setMovieETag(c, updated)
+	c.JSON(http.StatusOK, gin.H{
+		"movie":         updated,
+		"changedFields": changedFieldNames(changes),
+	})
+}
+
+func toJSONDocument(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
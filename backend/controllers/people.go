@@ -0,0 +1,704 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"stream4you/backend/database"
+	"stream4you/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var personCollection = database.DB.Collection("people")
+
+// normalizePersonName folds case, dots and whitespace so that "C. Nolan" and
+// "c nolan" compare equal.
+func normalizePersonName(name string) string {
+	name = strings.ToLower(strings.ReplaceAll(name, ".", " "))
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// movieCreditsSet returns the $set document for new credits. The legacy
+// director and cast strings are derived from the credits so that older
+// clients keep seeing the same people.
+func movieCreditsSet(credits []models.MovieCredit) bson.M {
+	directors := []string{}
+	cast := []string{}
+	for _, credit := range credits {
+		switch credit.Role {
+		case models.CreditRoleDirector:
+			directors = append(directors, credit.Name)
+		case models.CreditRoleActor:
+			cast = append(cast, credit.Name)
+		}
+	}
+
+	return bson.M{
+		"credits":  credits,
+		"director": strings.Join(directors, ", "),
+		"cast":     cast,
+	}
+}
+
+// resolveCredits looks up the people referenced by a credits request. It
+// returns validation errors for unknown people and invalid roles.
+func resolveCredits(reqs []models.MovieCreditRequest) ([]models.MovieCredit, []string) {
+	errs := []string{}
+	ids := []primitive.ObjectID{}
+	for i, req := range reqs {
+		id, err := primitive.ObjectIDFromHex(req.PersonID)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("credit %d: invalid person ID", i))
+			continue
+		}
+		if req.Character != "" && req.Role != models.CreditRoleActor {
+			errs = append(errs, fmt.Sprintf("credit %d: only actors have a character", i))
+		}
+		ids = append(ids, id)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	people := map[primitive.ObjectID]models.Person{}
+	if len(ids) > 0 {
+		cursor, err := personCollection.Find(context.Background(), bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return nil, []string{"failed to fetch people"}
+		}
+		defer cursor.Close(context.Background())
+
+		var found []models.Person
+		if err := cursor.All(context.Background(), &found); err != nil {
+			return nil, []string{"failed to decode people"}
+		}
+		for _, person := range found {
+			people[person.ID] = person
+		}
+	}
+
+	credits := []models.MovieCredit{}
+	for i, req := range reqs {
+		person, ok := people[ids[i]]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("credit %d: person %s not found", i, req.PersonID))
+			continue
+		}
+		credits = append(credits, models.MovieCredit{
+			PersonID:  person.ID,
+			Name:      person.Name,
+			Role:      req.Role,
+			Character: strings.TrimSpace(req.Character),
+		})
+	}
+	return credits, errs
+}
+
+func GetPeople(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	skip := (page - 1) * limit
+
+	filter := bson.M{}
+	if search := c.Query("search"); search != "" {
+		filter["$or"] = []bson.M{
+			{"name": bson.M{"$regex": search, "$options": "i"}},
+			{"aliases": bson.M{"$regex": search, "$options": "i"}},
+		}
+	}
+
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(limit)).SetSort(bson.M{"name": 1})
+	cursor, err := personCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	people := []models.Person{}
+	if err := cursor.All(context.Background(), &people); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode people"})
+		return
+	}
+
+	total, _ := personCollection.CountDocuments(context.Background(), filter)
+
+	c.JSON(http.StatusOK, gin.H{
+		"people": people,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// GetPerson returns a person with their filmography, newest movies first.
+func GetPerson(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid person ID"})
+		return
+	}
+
+	var person models.Person
+	err = personCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&person)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "year", Value: -1}, {Key: "title", Value: 1}})
+	cursor, err := movieCollection.Find(context.Background(), availableMovies(bson.M{"credits.personId": objectID}), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch filmography"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var movies []models.Movie
+	if err := cursor.All(context.Background(), &movies); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode filmography"})
+		return
+	}
+
+	filmography := []models.FilmographyEntry{}
+	for _, movie := range movies {
+		entry := models.FilmographyEntry{
+			MovieID:   movie.ID,
+			Title:     movie.Title,
+			Year:      movie.Year,
+			PosterURL: movie.PosterURL,
+			Roles:     []models.MovieCredit{},
+		}
+		for _, credit := range movie.Credits {
+			if credit.PersonID == objectID {
+				entry.Roles = append(entry.Roles, credit)
+			}
+		}
+		filmography = append(filmography, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"person":      person,
+		"filmography": filmography,
+	})
+}
+
+func CreatePerson(c *gin.Context) {
+	var req models.PersonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	aliases := req.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+
+	person := models.Person{
+		ID:        primitive.NewObjectID(),
+		Name:      strings.TrimSpace(req.Name),
+		Aliases:   aliases,
+		Bio:       req.Bio,
+		PhotoURL:  req.PhotoURL,
+		BirthDate: req.BirthDate,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if _, err := personCollection.InsertOne(context.Background(), person); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create person"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, person)
+}
+
+// UpdatePerson replaces a person's details. A new name is copied into the
+// credits of all movies the person appears in.
+func UpdatePerson(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid person ID"})
+		return
+	}
+
+	var req models.PersonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	aliases := req.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+
+	var person models.Person
+	err = personCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": objectID},
+		bson.M{"$set": bson.M{
+			"name":      strings.TrimSpace(req.Name),
+			"aliases":   aliases,
+			"bio":       req.Bio,
+			"photoUrl":  req.PhotoURL,
+			"birthDate": req.BirthDate,
+			"updatedAt": time.Now(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&person)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update person"})
+		return
+	}
+
+	if err := renameCredits(person, userObjectID); err != nil {
+		log.Printf("person %s: failed to update credits: %v", person.ID.Hex(), err)
+	}
+
+	c.JSON(http.StatusOK, person)
+}
+
+// renameCredits copies the person's current name into every movie credit
+// that still carries an old one. Movies in the trash are left alone and
+// pick up the name the next time their credits are saved.
+func renameCredits(person models.Person, userID primitive.ObjectID) error {
+	filter := bson.M{"credits": bson.M{"$elemMatch": bson.M{
+		"personId": person.ID,
+		"name":     bson.M{"$ne": person.Name},
+	}}}
+	cursor, err := movieCollection.Find(context.Background(), notDeleted(filter))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	var movies []models.Movie
+	if err := cursor.All(context.Background(), &movies); err != nil {
+		return err
+	}
+
+	for _, movie := range movies {
+		credits := make([]models.MovieCredit, len(movie.Credits))
+		copy(credits, movie.Credits)
+		for i := range credits {
+			if credits[i].PersonID == person.ID {
+				credits[i].Name = person.Name
+			}
+		}
+		_, _, err := applyMovieUpdate(movie.ID, movieUpdate{
+			Set:    movieCreditsSet(credits),
+			UserID: userID,
+			Action: models.RevisionActionUpdate,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeletePerson removes a person who is not credited in any movie.
+func DeletePerson(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid person ID"})
+		return
+	}
+
+	count, _ := movieCollection.CountDocuments(context.Background(), bson.M{"credits.personId": objectID})
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Person is still credited in movies", "movies": count})
+		return
+	}
+
+	result, err := personCollection.DeleteOne(context.Background(), bson.M{"_id": objectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete person"})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Person deleted successfully"})
+}
+
+// SetMovieCredits replaces the credits of a movie. Director and cast are
+// updated to match.
+func SetMovieCredits(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+
+	var req struct {
+		Credits []models.MovieCreditRequest `json:"credits" binding:"dive"`
+		Version *int                        `json:"version,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expected, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	viaIfMatch := expected != nil
+	if expected == nil {
+		expected = req.Version
+	}
+
+	credits, errs := resolveCredits(req.Credits)
+	if len(errs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid credits", "details": errs})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	movie, changes, err := applyMovieUpdate(objectID, movieUpdate{
+		Set:             movieCreditsSet(credits),
+		ExpectedVersion: expected,
+		UserID:          userObjectID,
+		Action:          models.RevisionActionUpdate,
+	})
+	if err != nil {
+		respondMovieUpdateError(c, err, viaIfMatch)
+		return
+	}
+
+	setMovieETag(c, movie)
+	c.JSON(http.StatusOK, gin.H{"movie": movie, "changedFields": changedFieldNames(changes)})
+}
+
+// personCandidate collects the spellings that the migration attributes to
+// one person, either an existing record or one it is about to create.
+type personCandidate struct {
+	person    models.Person
+	isNew     bool
+	spellings map[string]int
+}
+
+func (p *personCandidate) add(spelling string) {
+	p.spellings[spelling]++
+}
+
+// name picks the most used spelling; ties go to the longer, more complete one.
+func (p *personCandidate) name() string {
+	best := ""
+	for spelling, count := range p.spellings {
+		switch {
+		case best == "":
+			best = spelling
+		case count != p.spellings[best]:
+			if count > p.spellings[best] {
+				best = spelling
+			}
+		case len(spelling) != len(best):
+			if len(spelling) > len(best) {
+				best = spelling
+			}
+		case spelling < best:
+			best = spelling
+		}
+	}
+	return best
+}
+
+// isAbbreviatedName reports whether all names but the last are initials,
+// as in "C. Nolan" or "J R R Tolkien".
+func isAbbreviatedName(normalized string) bool {
+	tokens := strings.Fields(normalized)
+	if len(tokens) < 2 {
+		return false
+	}
+	for _, token := range tokens[:len(tokens)-1] {
+		if len([]rune(token)) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAbbreviation reports whether a full name fits an abbreviated one:
+// same last name and the same first initial.
+func matchesAbbreviation(full, abbreviated string) bool {
+	fullTokens, abbrTokens := strings.Fields(full), strings.Fields(abbreviated)
+	if len(fullTokens) < 2 || isAbbreviatedName(full) {
+		return false
+	}
+	return fullTokens[len(fullTokens)-1] == abbrTokens[len(abbrTokens)-1] &&
+		strings.HasPrefix(fullTokens[0], abbrTokens[0])
+}
+
+func splitDirectorNames(director string) []string {
+	names := []string{}
+	for _, name := range strings.Split(director, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// MigratePeople turns the director and cast strings of movies without
+// credits into people records and credits. Spellings that normalise to the
+// same name are merged, and abbreviated names such as "C. Nolan" are merged
+// into the only full name they can stand for; ambiguous abbreviations become
+// people of their own and are reported. Existing people (by name or alias)
+// are reused, so the migration can run again after new imports. With
+// dryRun=true nothing is written.
+func MigratePeople(c *gin.Context) {
+	dryRun := c.Query("dryRun") == "true"
+
+	userID, _ := c.Get("userId")
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	cursor, err := personCollection.Find(context.Background(), bson.M{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+	var existing []models.Person
+	err = cursor.All(context.Background(), &existing)
+	cursor.Close(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode people"})
+		return
+	}
+
+	filter := notDeleted(bson.M{"$or": []bson.M{
+		{"credits": bson.M{"$exists": false}},
+		{"credits": bson.M{"$size": 0}},
+	}})
+	cursor, err = movieCollection.Find(context.Background(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movies"})
+		return
+	}
+	var movies []models.Movie
+	err = cursor.All(context.Background(), &movies)
+	cursor.Close(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode movies"})
+		return
+	}
+
+	candidates := []*personCandidate{}
+	byName := map[string]*personCandidate{}
+	for _, person := range existing {
+		candidate := &personCandidate{person: person, spellings: map[string]int{}}
+		candidates = append(candidates, candidate)
+		for _, name := range append([]string{person.Name}, person.Aliases...) {
+			if key := normalizePersonName(name); byName[key] == nil {
+				byName[key] = candidate
+			}
+		}
+	}
+
+	// Every spelling used in the movies, keyed by normalised name
+	spellings := map[string][]string{}
+	for _, movie := range movies {
+		names := append(splitDirectorNames(movie.Director), movie.Cast...)
+		for _, name := range names {
+			name = strings.Join(strings.Fields(name), " ")
+			key := normalizePersonName(name)
+			if key == "" {
+				continue
+			}
+			spellings[key] = append(spellings[key], name)
+		}
+	}
+	keys := make([]string, 0, len(spellings))
+	for key := range spellings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	newCandidate := func(key string) *personCandidate {
+		candidate := &personCandidate{isNew: true, spellings: map[string]int{}}
+		candidates = append(candidates, candidate)
+		byName[key] = candidate
+		return candidate
+	}
+
+	// Full names first, so abbreviations can be matched against them
+	for _, key := range keys {
+		if isAbbreviatedName(key) {
+			continue
+		}
+		candidate := byName[key]
+		if candidate == nil {
+			candidate = newCandidate(key)
+		}
+		for _, spelling := range spellings[key] {
+			candidate.add(spelling)
+		}
+	}
+
+	ambiguous := []string{}
+	for _, key := range keys {
+		if !isAbbreviatedName(key) {
+			continue
+		}
+		candidate := byName[key]
+		if candidate == nil {
+			var matches []*personCandidate
+			seen := map[*personCandidate]bool{}
+			for name, match := range byName {
+				if !seen[match] && matchesAbbreviation(name, key) {
+					seen[match] = true
+					matches = append(matches, match)
+				}
+			}
+			if len(matches) == 1 {
+				candidate = matches[0]
+				byName[key] = candidate
+			} else {
+				if len(matches) > 1 {
+					ambiguous = append(ambiguous, spellings[key][0])
+				}
+				candidate = newCandidate(key)
+			}
+		}
+		for _, spelling := range spellings[key] {
+			candidate.add(spelling)
+		}
+	}
+
+	// Settle names and aliases
+	created := []models.Person{}
+	aliasesAdded := map[primitive.ObjectID][]string{}
+	now := time.Now()
+	for _, candidate := range candidates {
+		if candidate.isNew {
+			name := candidate.name()
+			aliases := []string{}
+			for spelling := range candidate.spellings {
+				if spelling != name {
+					aliases = append(aliases, spelling)
+				}
+			}
+			sort.Strings(aliases)
+			candidate.person = models.Person{
+				ID:        primitive.NewObjectID(),
+				Name:      name,
+				Aliases:   aliases,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			created = append(created, candidate.person)
+			continue
+		}
+
+		known := map[string]bool{normalizePersonName(candidate.person.Name): true}
+		for _, alias := range candidate.person.Aliases {
+			known[normalizePersonName(alias)] = true
+		}
+		for spelling := range candidate.spellings {
+			if !known[normalizePersonName(spelling)] {
+				known[normalizePersonName(spelling)] = true
+				aliasesAdded[candidate.person.ID] = append(aliasesAdded[candidate.person.ID], spelling)
+			}
+		}
+	}
+
+	creditsFor := func(movie models.Movie) []models.MovieCredit {
+		credits := []models.MovieCredit{}
+		seen := map[string]bool{}
+		addCredit := func(name, role string) {
+			candidate := byName[normalizePersonName(name)]
+			if candidate == nil {
+				return
+			}
+			person := candidate.person
+			if key := person.ID.Hex() + role; !seen[key] {
+				seen[key] = true
+				credits = append(credits, models.MovieCredit{PersonID: person.ID, Name: person.Name, Role: role})
+			}
+		}
+		for _, name := range splitDirectorNames(movie.Director) {
+			addCredit(name, models.CreditRoleDirector)
+		}
+		for _, name := range movie.Cast {
+			addCredit(name, models.CreditRoleActor)
+		}
+		return credits
+	}
+
+	moviesUpdated := 0
+	failed := []gin.H{}
+	if !dryRun {
+		for _, person := range created {
+			if _, err := personCollection.InsertOne(context.Background(), person); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create people"})
+				return
+			}
+		}
+		for personID, aliases := range aliasesAdded {
+			personCollection.UpdateOne(
+				context.Background(),
+				bson.M{"_id": personID},
+				bson.M{"$addToSet": bson.M{"aliases": bson.M{"$each": aliases}}, "$set": bson.M{"updatedAt": now}},
+			)
+		}
+	}
+	for _, movie := range movies {
+		credits := creditsFor(movie)
+		if len(credits) == 0 {
+			continue
+		}
+		if dryRun {
+			moviesUpdated++
+			continue
+		}
+		version := movie.Version
+		_, _, err := applyMovieUpdate(movie.ID, movieUpdate{
+			Set:             movieCreditsSet(credits),
+			ExpectedVersion: &version,
+			UserID:          userObjectID,
+			Action:          models.RevisionActionMigrate,
+		})
+		if err != nil {
+			failed = append(failed, gin.H{"movieId": movie.ID, "error": err.Error()})
+			continue
+		}
+		moviesUpdated++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dryRun":        dryRun,
+		"peopleCreated": created,
+		"aliasesAdded":  len(aliasesAdded),
+		"moviesUpdated": moviesUpdated,
+		"ambiguous":     ambiguous,
+		"failed":        failed,
+	})
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"stream4you/backend/config"
+	"stream4you/backend/media"
+	"stream4you/backend/models"
+	"stream4you/backend/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const mediaProbeTimeout = 2 * time.Minute
+
+// probeSlots limits how many videos are probed at once, as an import can
+// link many of them.
+var probeSlots = make(chan struct{}, 2)
+
+// mediaProber inspects uploaded and linked videos.
+var mediaProber media.MediaProber = media.FFprobe{Path: config.AppConfig.FFprobePath}
+
+// probeMovieVideo probes the video at videoPath and stores the result as
+// the movie's mediaInfo, unless the movie got another video in between.
+// The movie's duration is taken from it.
+func probeMovieVideo(movieID primitive.ObjectID, videoPath string) (models.MediaInfo, error) {
+	probeSlots <- struct{}{}
+	defer func() { <-probeSlots }()
+	ctx, cancel := context.WithTimeout(context.Background(), mediaProbeTimeout)
+	defer cancel()
+
+	info := models.MediaInfo{Source: videoPath, ProbedAt: time.Now()}
+	result, err := probeMediaFile(ctx, videoPath)
+	if errors.Is(err, exec.ErrNotFound) {
+		log.Printf("ffprobe not found at %q, videos are stored without media info", config.AppConfig.FFprobePath)
+		return info, err
+	}
+	if err != nil {
+		log.Printf("movie %s: failed to probe %s: %v", movieID.Hex(), videoPath, err)
+		info.Error = err.Error()
+	} else {
+		info = mediaInfo(videoPath, result)
+	}
+
+	updated, err := movieCollection.UpdateOne(context.Background(),
+		notDeleted(bson.M{"_id": movieID, "videoUrl": videoPath}),
+		bson.M{"$set": bson.M{"mediaInfo": info}})
+	if err != nil {
+		return info, err
+	}
+	if updated.MatchedCount == 0 || info.Duration <= 0 {
+		return info, nil
+	}
+
+	minutes := max(int(math.Round(info.Duration/60)), 1)
+	if _, _, err := applyMovieUpdate(movieID, movieUpdate{
+		Set:    bson.M{"duration": minutes},
+		Action: models.RevisionActionUpdate,
+	}); err != nil {
+		log.Printf("movie %s: failed to set duration: %v", movieID.Hex(), err)
+	}
+	return info, nil
+}
+
+// probeMediaFile probes a stored video. Stores that presign URLs let
+// ffprobe read just the parts it needs; others are downloaded first.
+func probeMediaFile(ctx context.Context, videoPath string) (media.ProbeResult, error) {
+	key, ok := mediaKey(videoPath)
+	if !ok {
+		return media.ProbeResult{}, errInvalidVideoPath
+	}
+	if _, local := mediaStore.(*storage.LocalStore); !local {
+		if url, _, ok := presignMedia(key, time.Duration(config.AppConfig.PresignExpiry)*time.Second); ok {
+			return mediaProber.Probe(ctx, url)
+		}
+	}
+
+	input, cleanup, err := mediaInputFile(ctx, key)
+	if err != nil {
+		return media.ProbeResult{}, err
+	}
+	defer cleanup()
+	return mediaProber.Probe(ctx, input)
+}
+
+func mediaInfo(videoPath string, result media.ProbeResult) models.MediaInfo {
+	info := models.MediaInfo{
+		Source:      videoPath,
+		Container:   result.Container,
+		ContentType: result.ContentType,
+		Duration:    result.Duration,
+		Bitrate:     result.Bitrate,
+		Width:       result.Width,
+		Height:      result.Height,
+		VideoCodec:  result.VideoCodec,
+		Audio:       []models.MediaAudioStream{},
+		Subtitles:   []models.MediaSubtitleStream{},
+		Chapters:    []models.Chapter{},
+		ProbedAt:    time.Now(),
+	}
+	for _, stream := range result.Audio {
+		info.Audio = append(info.Audio, models.MediaAudioStream{
+			Language:      stream.Language,
+			Title:         stream.Title,
+			Codec:         stream.Codec,
+			Channels:      stream.Channels,
+			ChannelLayout: stream.ChannelLayout,
+			Default:       stream.Default,
+		})
+	}
+	for _, stream := range result.Subtitles {
+		info.Subtitles = append(info.Subtitles, models.MediaSubtitleStream{
+			Language: stream.Language,
+			Title:    stream.Title,
+			Codec:    stream.Codec,
+			Forced:   stream.Forced,
+		})
+	}
+	for _, chapter := range result.Chapters {
+		info.Chapters = append(info.Chapters, models.Chapter{Title: chapter.Title, Start: chapter.Start, End: chapter.End})
+	}
+	return info
+}
+
+// movieContentType is the MIME type of the movie's video as found by
+// probing, or "" if the current video has not been probed.
+func movieContentType(movie models.Movie) string {
+	if movie.MediaInfo == nil || movie.MediaInfo.Source != movieVideoPath(movie) {
+		return ""
+	}
+	return movie.MediaInfo.ContentType
+}
+
+// ProbeMovie probes a movie's current video again, e.g. for movies from
+// before probing or after the file was replaced in place.
+func ProbeMovie(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+
+	var movie models.Movie
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": objectID})).Decode(&movie)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	if movie.VideoURL == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Movie has no video"})
+		return
+	}
+
+	info, err := probeMovieVideo(objectID, movie.VideoURL)
+	if errors.Is(err, exec.ErrNotFound) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "ffprobe is not available"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store media info"})
+		return
+	}
+	if info.Error != "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": info.Error, "mediaInfo": info})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mediaInfo": info})
+}
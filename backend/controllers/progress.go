@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stream4you/backend/config"
+	"stream4you/backend/database"
+	"stream4you/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var progressCollection = database.DB.Collection("watchProgress")
+
+// Positions before this are not worth resuming from, in seconds
+const minResumePosition = 30
+
+// watchedThreshold is the share of a movie after which it counts as
+// watched.
+func watchedThreshold() float64 {
+	return float64(config.AppConfig.WatchedThreshold) / 100
+}
+
+// finished tells whether a position is past the watched threshold or, for
+// movies with a credits marker, in the credits.
+func finished(position, duration, creditsStart float64) bool {
+	return position >= duration*watchedThreshold() || (creditsStart > 0 && position >= creditsStart)
+}
+
+// resumePosition is where playback continues: the last position, unless
+// the user barely started or already reached the end.
+func resumePosition(progress models.WatchProgress) float64 {
+	if progress.Position < minResumePosition || finished(progress.Position, progress.Duration, progress.CreditsStart) {
+		return 0
+	}
+	return progress.Position
+}
+
+// UpdateWatchProgress stores a playback heartbeat. Players send one every
+// few seconds while a movie is playing and when playback stops.
+func UpdateWatchProgress(c *gin.Context) {
+	var req models.ProgressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	movie, ok := findStreamableMovie(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// The heartbeat also keeps the playback session alive
+	if req.SessionID != "" {
+		touchUserSession(userObjectID, req.SessionID)
+	}
+
+	now := time.Now()
+	position := req.Position
+	if position > req.Duration {
+		position = req.Duration
+	}
+	creditsStart := movieCreditsStart(movie)
+	set := bson.M{"position": position, "duration": req.Duration, "creditsStart": creditsStart, "updatedAt": now}
+	if finished(position, req.Duration, creditsStart) {
+		set["watched"] = true
+		set["watchedAt"] = now
+	}
+
+	var progress models.WatchProgress
+	err = progressCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"userId": userObjectID, "movieId": movie.ID},
+		bson.M{"$set": set, "$setOnInsert": bson.M{"createdAt": now}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&progress)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save progress"})
+		return
+	}
+
+	progress.ResumePosition = resumePosition(progress)
+	c.JSON(http.StatusOK, progress)
+}
+
+// userWatchProgress returns the logged-in user's progress in a movie, or
+// nil for anonymous requests and movies the user has not started.
+func userWatchProgress(c *gin.Context, movieID primitive.ObjectID) *models.WatchProgress {
+	userID, exists := c.Get("userId")
+	if !exists {
+		return nil
+	}
+	userObjectID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		return nil
+	}
+
+	var progress models.WatchProgress
+	err = progressCollection.FindOne(context.Background(), bson.M{"userId": userObjectID, "movieId": movieID}).Decode(&progress)
+	if err != nil {
+		return nil
+	}
+	progress.ResumePosition = resumePosition(progress)
+	return &progress
+}
+
+// GetContinueWatching lists the movies the user started but did not
+// finish, most recently watched first.
+func GetContinueWatching(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userObjectID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	filter := bson.M{
+		"userId":   userObjectID,
+		"position": bson.M{"$gte": minResumePosition},
+		"$expr": bson.M{"$and": bson.A{
+			bson.M{"$lt": bson.A{"$position", bson.M{"$multiply": bson.A{"$duration", watchedThreshold()}}}},
+			bson.M{"$or": bson.A{
+				bson.M{"$lte": bson.A{bson.M{"$ifNull": bson.A{"$creditsStart", 0}}, 0}},
+				bson.M{"$lt": bson.A{"$position", "$creditsStart"}},
+			}},
+		}},
+	}
+	// Some of the movies may no longer be available, so fetch a few more
+	opts := options.Find().SetSort(bson.M{"updatedAt": -1}).SetLimit(int64(limit * 2))
+	cursor, err := progressCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var progresses []models.WatchProgress
+	if err := cursor.All(context.Background(), &progresses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode progress"})
+		return
+	}
+
+	movieIDs := []primitive.ObjectID{}
+	for _, progress := range progresses {
+		movieIDs = append(movieIDs, progress.MovieID)
+	}
+	movieCursor, err := movieCollection.Find(context.Background(), availableMovies(bson.M{"_id": bson.M{"$in": movieIDs}}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movies"})
+		return
+	}
+	defer movieCursor.Close(context.Background())
+
+	var movies []models.Movie
+	if err := movieCursor.All(context.Background(), &movies); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode movies"})
+		return
+	}
+
+	lang := requestLanguage(c)
+	genres := genreNames(lang)
+	moviesByID := map[primitive.ObjectID]models.Movie{}
+	for _, movie := range movies {
+		localizeMovie(&movie, lang, genres)
+		moviesByID[movie.ID] = movie
+	}
+
+	items := []gin.H{}
+	for _, progress := range progresses {
+		movie, ok := moviesByID[progress.MovieID]
+		if !ok {
+			continue
+		}
+		progress.ResumePosition = resumePosition(progress)
+		items = append(items, gin.H{"movie": movie, "progress": progress})
+		if len(items) == limit {
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items, "language": lang})
+}
@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"stream4you/backend/config"
+	"stream4you/backend/events"
+	"stream4you/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// availableMovies restricts a movie filter to movies users may see right
+// now: not in the trash, not a draft and inside the availability window.
+// The window is checked here too, so titles appear and disappear on time
+// even before the scheduler has updated their status.
+func availableMovies(filter bson.M) bson.M {
+	now := time.Now()
+	notDeleted(filter)
+	filter["status"] = bson.M{"$in": bson.A{nil, models.MovieStatusPublished, models.MovieStatusScheduled}}
+
+	window := []bson.M{
+		{"$or": []bson.M{{"availableFrom": nil}, {"availableFrom": bson.M{"$lte": now}}}},
+		{"$or": []bson.M{{"availableUntil": nil}, {"availableUntil": bson.M{"$gt": now}}}},
+	}
+	if and, ok := filter["$and"].([]bson.M); ok {
+		window = append(and, window...)
+	}
+	filter["$and"] = window
+	return filter
+}
+
+// isMovieAvailable is the availableMovies check for a loaded movie.
+func isMovieAvailable(movie models.Movie, now time.Time) bool {
+	if movie.DeletedAt != nil || movie.Status == models.MovieStatusDraft {
+		return false
+	}
+	if movie.AvailableFrom != nil && movie.AvailableFrom.After(now) {
+		return false
+	}
+	if movie.AvailableUntil != nil && !movie.AvailableUntil.After(now) {
+		return false
+	}
+	return true
+}
+
+func isAdmin(c *gin.Context) bool {
+	role, _ := c.Get("role")
+	return role == "admin"
+}
+
+// visibleMovies is availableMovies for users. Admins also see drafts and
+// titles outside their window when they ask for includeUnpublished=true.
+func visibleMovies(c *gin.Context, filter bson.M) bson.M {
+	if isAdmin(c) && c.Query("includeUnpublished") == "true" {
+		return notDeleted(filter)
+	}
+	return availableMovies(filter)
+}
+
+// publicationStatus derives the stored status from the editorial state and
+// the availability window.
+func publicationStatus(status string, from, until *time.Time, now time.Time) string {
+	switch {
+	case status == models.MovieStatusDraft:
+		return models.MovieStatusDraft
+	case until != nil && !until.After(now):
+		return models.MovieStatusExpired
+	case from != nil && from.After(now):
+		return models.MovieStatusScheduled
+	}
+	return models.MovieStatusPublished
+}
+
+func validateAvailability(from, until *time.Time) []string {
+	if from != nil && until != nil && !until.After(*from) {
+		return []string{"availableUntil must be after availableFrom"}
+	}
+	return nil
+}
+
+func publishMovieEvent(eventType string, movie models.Movie) {
+	events.Publish(events.Event{Type: eventType, MovieID: movie.ID, Title: movie.Title})
+}
+
+// SetMoviePublication publishes or unpublishes a movie and sets its
+// availability window. Events are emitted when this makes the movie go live
+// or expire.
+func SetMoviePublication(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+
+	var req models.PublicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errs := validateAvailability(req.AvailableFrom, req.AvailableUntil); len(errs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid availability", "details": errs})
+		return
+	}
+
+	expected, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	viaIfMatch := expected != nil
+	if expected == nil {
+		expected = req.Version
+	}
+
+	var current models.Movie
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": objectID})).Decode(&current)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	if expected == nil {
+		expected = &current.Version
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	now := time.Now()
+	movie, changes, err := applyMovieUpdate(objectID, movieUpdate{
+		Set: bson.M{
+			"status":         publicationStatus(req.Status, req.AvailableFrom, req.AvailableUntil, now),
+			"availableFrom":  req.AvailableFrom,
+			"availableUntil": req.AvailableUntil,
+		},
+		ExpectedVersion: expected,
+		UserID:          userObjectID,
+		Action:          models.RevisionActionUpdate,
+	})
+	if err != nil {
+		respondMovieUpdateError(c, err, viaIfMatch)
+		return
+	}
+
+	wasAvailable, isAvailable := isMovieAvailable(current, now), isMovieAvailable(movie, now)
+	switch {
+	case !wasAvailable && isAvailable:
+		publishMovieEvent(events.MovieLive, movie)
+	case wasAvailable && !isAvailable && movie.Status == models.MovieStatusExpired:
+		publishMovieEvent(events.MovieExpired, movie)
+	}
+
+	setMovieETag(c, movie)
+	c.JSON(http.StatusOK, gin.H{"movie": movie, "changedFields": changedFieldNames(changes)})
+}
+
+// UpdatePublicationStates moves movies whose window opened or closed to
+// their new status and emits the matching events. It returns the number of
+// movies that went live and expired.
+func UpdatePublicationStates() (int, int, error) {
+	now := time.Now()
+
+	// Scheduled (or expired, after the window was moved) and now inside
+	// the window
+	live, err := flipMovieStatus(bson.M{
+		"status": bson.M{"$in": bson.A{models.MovieStatusScheduled, models.MovieStatusExpired}},
+		"$and": []bson.M{
+			{"$or": []bson.M{{"availableFrom": nil}, {"availableFrom": bson.M{"$lte": now}}}},
+			{"$or": []bson.M{{"availableUntil": nil}, {"availableUntil": bson.M{"$gt": now}}}},
+		},
+	}, models.MovieStatusPublished, events.MovieLive)
+	if err != nil {
+		return live, 0, err
+	}
+
+	expired, err := flipMovieStatus(bson.M{
+		"status":         bson.M{"$in": bson.A{nil, models.MovieStatusPublished, models.MovieStatusScheduled}},
+		"availableUntil": bson.M{"$lte": now},
+	}, models.MovieStatusExpired, events.MovieExpired)
+	if err != nil {
+		return live, expired, err
+	}
+
+	// Published again before a window that lies ahead, e.g. after a rollback
+	_, err = flipMovieStatus(bson.M{
+		"status":        models.MovieStatusPublished,
+		"availableFrom": bson.M{"$gt": now},
+	}, models.MovieStatusScheduled, "")
+	return live, expired, err
+}
+
+// flipMovieStatus sets status on all movies matching filter, as a new
+// revision. Each movie is only updated if it did not change in between, so
+// an event is emitted once per transition even with several servers
+// running.
+func flipMovieStatus(filter bson.M, status, eventType string) (int, error) {
+	cursor, err := movieCollection.Find(context.Background(), notDeleted(filter))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	var movies []models.Movie
+	if err := cursor.All(context.Background(), &movies); err != nil {
+		return 0, err
+	}
+
+	flipped := 0
+	for _, movie := range movies {
+		updated, changes, err := applyMovieUpdate(movie.ID, movieUpdate{
+			Set:             bson.M{"status": status},
+			ExpectedVersion: &movie.Version,
+			Action:          models.RevisionActionUpdate,
+		})
+		var conflict *versionConflictError
+		if errors.As(err, &conflict) || errors.Is(err, errMovieNotFound) {
+			// Changed or deleted in between, the next check sees it again
+			continue
+		}
+		if err != nil {
+			log.Printf("movie %s: failed to set status %s: %v", movie.ID.Hex(), status, err)
+			continue
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		flipped++
+		if eventType != "" {
+			publishMovieEvent(eventType, updated)
+		}
+	}
+	return flipped, nil
+}
+
+// StartPublicationScheduler runs UpdatePublicationStates periodically in the
+// background.
+func StartPublicationScheduler() {
+	interval := time.Duration(config.AppConfig.PublishCheckInterval) * time.Second
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			live, expired, err := UpdatePublicationStates()
+			if err != nil {
+				log.Println("Publication check failed:", err)
+			} else if live > 0 || expired > 0 {
+				log.Printf("Publication check: %d movies went live, %d expired", live, expired)
+			}
+			<-ticker.C
+		}
+	}()
+}
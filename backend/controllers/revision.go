@@ -0,0 +1,409 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"stream4you/backend/database"
+	"stream4you/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var revisionCollection = database.DB.Collection("movieRevisions")
+
+// editableMovieFields are the metadata fields that PUT and PATCH change,
+// by BSON name.
+var editableMovieFields = []string{
+	"externalId", "title", "description", "genre", "year", "duration",
+	"posterUrl", "videoUrl", "director", "cast",
+}
+
+// trackedMovieFields are all fields recorded in the revision history: the
+// editable metadata plus credits, translations, publication, images,
+// subtitles, chapters and extras, which are set through their own
+// endpoints.
+var trackedMovieFields = append(append([]string{}, editableMovieFields...),
+	"credits", "translations", "status", "availableFrom", "availableUntil", "images", "subtitles", "chapters", "markers",
+	"extras")
+
+var errMovieNotFound = errors.New("movie not found")
+
+type versionConflictError struct {
+	Current int
+}
+
+func (e *versionConflictError) Error() string {
+	return fmt.Sprintf("movie was modified concurrently (current version %d)", e.Current)
+}
+
+type movieUpdate struct {
+	Set bson.M
+	// ExpectedVersion makes the update fail with a versionConflictError when
+	// the stored movie has a different version. nil skips the check.
+	ExpectedVersion *int
+	UserID          primitive.ObjectID
+	Action          string
+	RolledBackTo    *int
+}
+
+// applyMovieUpdate writes the fields in update.Set that actually differ from
+// the stored movie, bumps the movie version and records the change as a
+// revision. It returns the updated movie and the changed fields.
+func applyMovieUpdate(movieID primitive.ObjectID, update movieUpdate) (models.Movie, []models.FieldChange, error) {
+	var current models.Movie
+	err := movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": movieID})).Decode(&current)
+	if err == mongo.ErrNoDocuments {
+		return current, nil, errMovieNotFound
+	}
+	if err != nil {
+		return current, nil, err
+	}
+
+	if update.ExpectedVersion != nil && *update.ExpectedVersion != current.Version {
+		return current, nil, &versionConflictError{Current: current.Version}
+	}
+
+	currentValues := movieFieldValues(current)
+	changes := []models.FieldChange{}
+	set := bson.M{}
+	for _, field := range trackedMovieFields {
+		value, ok := update.Set[field]
+		if !ok || fieldValuesEqual(currentValues[field], value) {
+			continue
+		}
+		set[field] = value
+		changes = append(changes, models.FieldChange{Field: field, Old: currentValues[field], New: value})
+	}
+	if len(changes) == 0 {
+		return current, changes, nil
+	}
+
+	now := time.Now()
+	set["updatedAt"] = now
+	result := movieCollection.FindOneAndUpdate(
+		context.Background(),
+		notDeleted(bson.M{"_id": movieID, "version": versionFilter(current.Version)}),
+		bson.M{"$set": set, "$inc": bson.M{"version": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+
+	var movie models.Movie
+	if err := result.Decode(&movie); err != nil {
+		if err == mongo.ErrNoDocuments {
+			// Someone else saved in between our read and write
+			return current, nil, &versionConflictError{Current: current.Version + 1}
+		}
+		return current, nil, err
+	}
+
+	revision := models.MovieRevision{
+		ID:           primitive.NewObjectID(),
+		MovieID:      movieID,
+		Version:      movie.Version,
+		Action:       update.Action,
+		Changes:      changes,
+		RolledBackTo: update.RolledBackTo,
+		ChangedBy:    update.UserID,
+		ChangedAt:    now,
+	}
+	if _, err := revisionCollection.InsertOne(context.Background(), revision); err != nil {
+		log.Printf("movie %s: failed to record revision %d: %v", movieID.Hex(), movie.Version, err)
+	}
+	if videoPath, ok := set["videoUrl"].(string); ok && videoPath != "" {
+		go probeMovieVideo(movieID, videoPath)
+	}
+
+	return movie, changes, nil
+}
+
+// setMovieTrashed moves a movie to the trash or restores it from there. It
+// bumps the version and records a revision with the deletedAt change in the
+// same way applyMovieUpdate does; rollbacks leave the field alone.
+func setMovieTrashed(movieID, userID primitive.ObjectID, trashed bool) error {
+	now := time.Now()
+	filter := bson.M{"_id": movieID, "deletedAt": bson.M{"$exists": true}}
+	update := bson.M{
+		"$unset": bson.M{"deletedAt": "", "deletedBy": ""},
+		"$set":   bson.M{"updatedAt": now},
+		"$inc":   bson.M{"version": 1},
+	}
+	action := models.RevisionActionRestore
+	if trashed {
+		filter = notDeleted(bson.M{"_id": movieID})
+		update = bson.M{
+			"$set": bson.M{"deletedAt": now, "deletedBy": userID, "updatedAt": now},
+			"$inc": bson.M{"version": 1},
+		}
+		action = models.RevisionActionDelete
+	}
+
+	var previous models.Movie
+	err := movieCollection.FindOneAndUpdate(context.Background(), filter, update).Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		return errMovieNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	change := models.FieldChange{Field: "deletedAt"}
+	if trashed {
+		change.New = now
+	} else {
+		change.Old = previous.DeletedAt
+	}
+	revision := models.MovieRevision{
+		ID:        primitive.NewObjectID(),
+		MovieID:   movieID,
+		Version:   previous.Version + 1,
+		Action:    action,
+		Changes:   []models.FieldChange{change},
+		ChangedBy: userID,
+		ChangedAt: now,
+	}
+	if _, err := revisionCollection.InsertOne(context.Background(), revision); err != nil {
+		log.Printf("movie %s: failed to record revision %d: %v", movieID.Hex(), revision.Version, err)
+	}
+	return nil
+}
+
+// recordMovieCreated stores the initial revision of a newly created movie.
+func recordMovieCreated(movie models.Movie, userID primitive.ObjectID) {
+	changes := []models.FieldChange{}
+	values := movieFieldValues(movie)
+	for _, field := range trackedMovieFields {
+		if value := values[field]; !fieldValuesEqual(nil, value) {
+			changes = append(changes, models.FieldChange{Field: field, New: value})
+		}
+	}
+
+	revision := models.MovieRevision{
+		ID:        primitive.NewObjectID(),
+		MovieID:   movie.ID,
+		Version:   movie.Version,
+		Action:    models.RevisionActionCreate,
+		Changes:   changes,
+		ChangedBy: userID,
+		ChangedAt: movie.CreatedAt,
+	}
+	if _, err := revisionCollection.InsertOne(context.Background(), revision); err != nil {
+		log.Printf("movie %s: failed to record initial revision: %v", movie.ID.Hex(), err)
+	}
+}
+
+// movieFieldValues returns the tracked fields of movie as they are stored.
+func movieFieldValues(movie models.Movie) bson.M {
+	values := bson.M{}
+	data, err := bson.Marshal(movie)
+	if err != nil {
+		return values
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return values
+	}
+	for _, field := range trackedMovieFields {
+		values[field] = doc[field]
+	}
+	return values
+}
+
+func changedFieldNames(changes []models.FieldChange) []string {
+	names := make([]string, 0, len(changes))
+	for _, change := range changes {
+		names = append(names, change.Field)
+	}
+	return names
+}
+
+// fieldValuesEqual compares two field values in their stored form. Missing
+// values, empty strings and empty lists are considered equal.
+func fieldValuesEqual(a, b interface{}) bool {
+	a, b = normalizeFieldValue(a), normalizeFieldValue(b)
+	return reflect.DeepEqual(a, b)
+}
+
+func normalizeFieldValue(value interface{}) interface{} {
+	data, err := bson.Marshal(bson.M{"v": value})
+	if err != nil {
+		return value
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return value
+	}
+
+	switch v := doc["v"].(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+	case primitive.A:
+		if len(v) == 0 {
+			return nil
+		}
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return doc["v"]
+}
+
+// versionFilter matches a stored version; movies created before versioning
+// have no version field, which counts as version 0.
+func versionFilter(version int) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
+}
+
+// ifMatchVersion parses an If-Match header holding a movie ETag. It returns
+// nil when the header is absent or "*".
+func ifMatchVersion(c *gin.Context) (*int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil {
+		return nil, errors.New("invalid If-Match header")
+	}
+	return &version, nil
+}
+
+func setMovieETag(c *gin.Context, movie models.Movie) {
+	c.Header("ETag", fmt.Sprintf(`"%d"`, movie.Version))
+}
+
+// respondMovieUpdateError maps applyMovieUpdate errors to HTTP responses.
+func respondMovieUpdateError(c *gin.Context, err error, viaIfMatch bool) {
+	var conflict *versionConflictError
+	switch {
+	case errors.Is(err, errMovieNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+	case errors.As(err, &conflict):
+		status := http.StatusConflict
+		if viaIfMatch {
+			status = http.StatusPreconditionFailed
+		}
+		c.JSON(status, gin.H{
+			"error":          "Movie was modified by someone else, reload and try again",
+			"currentVersion": conflict.Current,
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update movie"})
+	}
+}
+
+func GetMovieRevisions(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+
+	opts := options.Find().SetSort(bson.M{"version": -1})
+	cursor, err := revisionCollection.Find(context.Background(), bson.M{"movieId": objectID}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revisions"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	revisions := []models.MovieRevision{}
+	if err := cursor.All(context.Background(), &revisions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// RollbackMovie restores the metadata as it was at the given version. The
+// rollback itself is stored as a new revision, so it can be undone as well.
+func RollbackMovie(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+	target, err := strconv.Atoi(c.Param("version"))
+	if err != nil || target < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+	expected, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	count, _ := revisionCollection.CountDocuments(context.Background(), bson.M{"movieId": objectID, "version": target})
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+
+	// Undo every later revision, newest first, so each field ends up with
+	// the value it had right after the target version.
+	opts := options.Find().SetSort(bson.M{"version": -1})
+	cursor, err := revisionCollection.Find(context.Background(), bson.M{"movieId": objectID, "version": bson.M{"$gt": target}}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revisions"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var later []models.MovieRevision
+	if err := cursor.All(context.Background(), &later); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode revisions"})
+		return
+	}
+
+	set := bson.M{}
+	for _, revision := range later {
+		for _, change := range revision.Changes {
+			set[change.Field] = change.Old
+		}
+	}
+
+	movie, changes, err := applyMovieUpdate(objectID, movieUpdate{
+		Set:             set,
+		ExpectedVersion: expected,
+		UserID:          userObjectID,
+		Action:          models.RevisionActionRollback,
+		RolledBackTo:    &target,
+	})
+	if err != nil {
+		respondMovieUpdateError(c, err, expected != nil)
+		return
+	}
+
+	setMovieETag(c, movie)
+	c.JSON(http.StatusOK, gin.H{
+		"movie":   movie,
+		"changes": changes,
+	})
+}
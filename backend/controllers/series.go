@@ -0,0 +1,682 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"stream4you/backend/database"
+	"stream4you/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var seriesCollection = database.DB.Collection("series")
+var episodeCollection = database.DB.Collection("episodes")
+
+func GetSeriesList(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "12"))
+	skip := (page - 1) * limit
+
+	filter := catalogFilter(c)
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(limit)).SetSort(bson.M{"createdAt": -1})
+
+	cursor, err := seriesCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch series"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	series := []models.Series{}
+	if err := cursor.All(context.Background(), &series); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode series"})
+		return
+	}
+
+	total, _ := seriesCollection.CountDocuments(context.Background(), filter)
+
+	c.JSON(http.StatusOK, gin.H{
+		"series": series,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func GetSeries(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	var series models.Series
+	if err := seriesCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&series); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+
+	episodes, err := findEpisodes(bson.M{"seriesId": objectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episodes"})
+		return
+	}
+
+	reviews := []models.Review{}
+	cursor, err := reviewCollection.Find(context.Background(), bson.M{"seriesId": objectID})
+	if err == nil {
+		defer cursor.Close(context.Background())
+		cursor.All(context.Background(), &reviews)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series":   series,
+		"episodes": episodes,
+		"reviews":  reviews,
+	})
+}
+
+func GetSeasonEpisodes(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+	season, err := strconv.Atoi(c.Param("season"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid season number"})
+		return
+	}
+
+	episodes, err := findEpisodes(bson.M{"seriesId": objectID, "seasonNumber": season})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episodes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"episodes": episodes})
+}
+
+func GetEpisode(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid episode ID"})
+		return
+	}
+
+	var episode models.Episode
+	if err := episodeCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&episode); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, episode)
+}
+
+// GetNextEpisode returns the episode following the given one: the next
+// episode of the same season, otherwise the first episode of the next
+// season. "next" is null after the last episode.
+func GetNextEpisode(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid episode ID"})
+		return
+	}
+
+	var current models.Episode
+	if err := episodeCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&current); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+		return
+	}
+
+	filter := bson.M{
+		"seriesId": current.SeriesID,
+		"$or": []bson.M{
+			{"seasonNumber": current.SeasonNumber, "episodeNumber": bson.M{"$gt": current.EpisodeNumber}},
+			{"seasonNumber": bson.M{"$gt": current.SeasonNumber}},
+		},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "seasonNumber", Value: 1}, {Key: "episodeNumber", Value: 1}})
+
+	var next models.Episode
+	err = episodeCollection.FindOne(context.Background(), filter, opts).Decode(&next)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusOK, gin.H{"next": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch next episode"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"next": next})
+}
+
+func findEpisodes(filter bson.M) ([]models.Episode, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "seasonNumber", Value: 1}, {Key: "episodeNumber", Value: 1}})
+	cursor, err := episodeCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	episodes := []models.Episode{}
+	if err := cursor.All(context.Background(), &episodes); err != nil {
+		return nil, err
+	}
+	return episodes, nil
+}
+
+func CreateSeries(c *gin.Context) {
+	var req models.SeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	series := models.Series{
+		ID:          primitive.NewObjectID(),
+		Title:       req.Title,
+		Description: req.Description,
+		Genre:       req.Genre,
+		Year:        req.Year,
+		EndYear:     req.EndYear,
+		PosterURL:   req.PosterURL,
+		Creator:     req.Creator,
+		Cast:        req.Cast,
+		Seasons:     []models.Season{},
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		CreatedBy:   userObjectID,
+	}
+
+	if _, err := seriesCollection.InsertOne(context.Background(), series); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create series"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, series)
+}
+
+func UpdateSeries(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	var req models.SeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	update := bson.M{
+		"title":       req.Title,
+		"description": req.Description,
+		"genre":       req.Genre,
+		"year":        req.Year,
+		"endYear":     req.EndYear,
+		"posterUrl":   req.PosterURL,
+		"creator":     req.Creator,
+		"cast":        req.Cast,
+		"updatedAt":   time.Now(),
+	}
+
+	var series models.Series
+	err = seriesCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": objectID},
+		bson.M{"$set": update},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&series)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// DeleteSeries removes the series with all its episodes and reviews.
+func DeleteSeries(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	result, err := seriesCollection.DeleteOne(context.Background(), bson.M{"_id": objectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete series"})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+
+	episodeCollection.DeleteMany(context.Background(), bson.M{"seriesId": objectID})
+	reviewCollection.DeleteMany(context.Background(), bson.M{"seriesId": objectID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Series deleted successfully"})
+}
+
+// UpsertSeason creates or updates the metadata of a season.
+func UpsertSeason(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+	number, err := strconv.Atoi(c.Param("season"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid season number"})
+		return
+	}
+
+	var req models.SeasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var series models.Series
+	if err := seriesCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&series); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+
+	season := models.Season{Number: number, Title: req.Title, Year: req.Year, PosterURL: req.PosterURL}
+	seasons := []models.Season{season}
+	for _, existing := range series.Seasons {
+		if existing.Number != number {
+			seasons = append(seasons, existing)
+		}
+	}
+	sort.Slice(seasons, func(i, j int) bool { return seasons[i].Number < seasons[j].Number })
+
+	_, err = seriesCollection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": objectID},
+		bson.M{"$set": bson.M{"seasons": seasons, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save season"})
+		return
+	}
+
+	c.JSON(http.StatusOK, season)
+}
+
+// DeleteSeason removes a season together with its episodes.
+func DeleteSeason(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+	number, err := strconv.Atoi(c.Param("season"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid season number"})
+		return
+	}
+
+	result, err := seriesCollection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": objectID},
+		bson.M{
+			"$pull": bson.M{"seasons": bson.M{"number": number}},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete season"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+
+	episodeCollection.DeleteMany(context.Background(), bson.M{"seriesId": objectID, "seasonNumber": number})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Season deleted successfully"})
+}
+
+func CreateEpisode(c *gin.Context) {
+	seriesID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	var req models.EpisodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errs := validateMediaPath("videoUrl", req.VideoURL); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": strings.Join(errs, "; ")})
+		return
+	}
+
+	var series models.Series
+	if err := seriesCollection.FindOne(context.Background(), bson.M{"_id": seriesID}).Decode(&series); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+
+	count, _ := episodeCollection.CountDocuments(context.Background(), bson.M{
+		"seriesId":      seriesID,
+		"seasonNumber":  req.SeasonNumber,
+		"episodeNumber": req.EpisodeNumber,
+	})
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Episode number already exists in this season"})
+		return
+	}
+
+	episode := models.Episode{
+		ID:            primitive.NewObjectID(),
+		SeriesID:      seriesID,
+		SeasonNumber:  req.SeasonNumber,
+		EpisodeNumber: req.EpisodeNumber,
+		Title:         req.Title,
+		Description:   req.Description,
+		Duration:      req.Duration,
+		VideoURL:      req.VideoURL,
+		AirDate:       req.AirDate,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	if _, err := episodeCollection.InsertOne(context.Background(), episode); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create episode"})
+		return
+	}
+
+	// Episodes may be added before their season was described
+	seriesCollection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": seriesID, "seasons.number": bson.M{"$ne": req.SeasonNumber}},
+		bson.M{"$push": bson.M{"seasons": bson.M{
+			"$each": []models.Season{{Number: req.SeasonNumber}},
+			"$sort": bson.M{"number": 1},
+		}}},
+	)
+
+	c.JSON(http.StatusCreated, episode)
+}
+
+func UpdateEpisode(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid episode ID"})
+		return
+	}
+
+	var req models.EpisodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errs := validateMediaPath("videoUrl", req.VideoURL); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": strings.Join(errs, "; ")})
+		return
+	}
+
+	var current models.Episode
+	if err := episodeCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&current); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+		return
+	}
+
+	count, _ := episodeCollection.CountDocuments(context.Background(), bson.M{
+		"_id":           bson.M{"$ne": objectID},
+		"seriesId":      current.SeriesID,
+		"seasonNumber":  req.SeasonNumber,
+		"episodeNumber": req.EpisodeNumber,
+	})
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Episode number already exists in this season"})
+		return
+	}
+
+	update := bson.M{
+		"seasonNumber":  req.SeasonNumber,
+		"episodeNumber": req.EpisodeNumber,
+		"title":         req.Title,
+		"description":   req.Description,
+		"duration":      req.Duration,
+		"videoUrl":      req.VideoURL,
+		"airDate":       req.AirDate,
+		"updatedAt":     time.Now(),
+	}
+
+	var episode models.Episode
+	err = episodeCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": objectID},
+		bson.M{"$set": update},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&episode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update episode"})
+		return
+	}
+
+	c.JSON(http.StatusOK, episode)
+}
+
+func DeleteEpisode(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid episode ID"})
+		return
+	}
+
+	var episode models.Episode
+	if err := episodeCollection.FindOneAndDelete(context.Background(), bson.M{"_id": objectID}).Decode(&episode); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+		return
+	}
+
+	reviewCollection.DeleteMany(context.Background(), bson.M{"episodeId": objectID})
+	updateSeriesRating(episode.SeriesID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Episode deleted successfully"})
+}
+
+// AddSeriesReview rates a whole series or, with episodeId, one episode of it.
+// Both count towards the series rating.
+func AddSeriesReview(c *gin.Context) {
+	seriesID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	var req struct {
+		EpisodeID string `json:"episodeId"`
+		Rating    int    `json:"rating" binding:"required,min=1,max=5"`
+		Comment   string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	count, _ := seriesCollection.CountDocuments(context.Background(), bson.M{"_id": seriesID})
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+
+	var episodeID primitive.ObjectID
+	if req.EpisodeID != "" {
+		episodeID, err = primitive.ObjectIDFromHex(req.EpisodeID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid episode ID"})
+			return
+		}
+		count, _ := episodeCollection.CountDocuments(context.Background(), bson.M{"_id": episodeID, "seriesId": seriesID})
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+			return
+		}
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	review := models.Review{
+		ID:        primitive.NewObjectID(),
+		SeriesID:  seriesID,
+		EpisodeID: episodeID,
+		UserID:    userObjectID,
+		Rating:    req.Rating,
+		Comment:   req.Comment,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if _, err := reviewCollection.InsertOne(context.Background(), review); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create review"})
+		return
+	}
+
+	updateSeriesRating(seriesID)
+
+	c.JSON(http.StatusCreated, review)
+}
+
+func updateSeriesRating(seriesID primitive.ObjectID) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"seriesId": seriesID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"avg":   bson.M{"$avg": "$rating"},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+
+	cursor, err := reviewCollection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var results []struct {
+		Avg   float64 `bson:"avg"`
+		Count int     `bson:"count"`
+	}
+	cursor.All(context.Background(), &results)
+
+	rating, count := 0.0, 0
+	if len(results) > 0 {
+		rating, count = results[0].Avg, results[0].Count
+	}
+
+	seriesCollection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": seriesID},
+		bson.M{"$set": bson.M{"rating": rating, "ratingCount": count}},
+	)
+}
+
+// getSeriesRecommendations suggests series sharing the genres the user rated
+// highly, across both movie and series reviews. Without such reviews the
+// best rated series are returned.
+func getSeriesRecommendations(reviews []models.Review, movies []models.Movie) []models.Series {
+	genreScores := make(map[string]int)
+	reviewedSeries := make(map[primitive.ObjectID]bool)
+	seriesReviews := make(map[primitive.ObjectID]int)
+
+	for _, review := range reviews {
+		if !review.SeriesID.IsZero() {
+			reviewedSeries[review.SeriesID] = true
+			if review.Rating >= 4 {
+				seriesReviews[review.SeriesID] += review.Rating
+			}
+			continue
+		}
+		if review.Rating < 4 {
+			continue
+		}
+		for _, movie := range movies {
+			if movie.ID == review.MovieID {
+				for _, genre := range movie.Genre {
+					genreScores[genre] += review.Rating
+				}
+			}
+		}
+	}
+
+	cursor, err := seriesCollection.Find(context.Background(), bson.M{}, options.Find().SetSort(bson.M{"rating": -1}))
+	if err != nil {
+		return []models.Series{}
+	}
+	defer cursor.Close(context.Background())
+
+	var allSeries []models.Series
+	cursor.All(context.Background(), &allSeries)
+
+	for _, series := range allSeries {
+		if score, ok := seriesReviews[series.ID]; ok {
+			for _, genre := range series.Genre {
+				genreScores[genre] += score
+			}
+		}
+	}
+
+	type scored struct {
+		series models.Series
+		score  int
+	}
+	candidates := []scored{}
+	for _, series := range allSeries {
+		if reviewedSeries[series.ID] {
+			continue
+		}
+		score := 0
+		for _, genre := range series.Genre {
+			score += genreScores[genre]
+		}
+		if score > 0 || len(genreScores) == 0 {
+			candidates = append(candidates, scored{series: series, score: score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	recommendations := []models.Series{}
+	for _, candidate := range candidates {
+		if len(recommendations) == 5 {
+			break
+		}
+		recommendations = append(recommendations, candidate.series)
+	}
+	return recommendations
+}
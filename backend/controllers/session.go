@@ -0,0 +1,328 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"stream4you/backend/config"
+	"stream4you/backend/database"
+	"stream4you/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var sessionCollection = database.DB.Collection("playbackSessions")
+
+// streamSlotCollection holds one document per user listing the sessions
+// that take up a stream, see claimStreamSlot.
+var streamSlotCollection = database.DB.Collection("streamSlots")
+
+var (
+	errStreamLimitReached = errors.New("stream limit reached")
+	errSessionEnded       = errors.New("playback session ended")
+)
+
+// Stream requests refresh a session at most this often
+const sessionTouchInterval = 15 * time.Second
+
+func sessionTimeout() time.Duration {
+	return time.Duration(config.AppConfig.PlaybackSessionTimeout) * time.Second
+}
+
+// activeSessions selects a user's sessions that count towards the limit.
+func activeSessions(userID primitive.ObjectID, now time.Time) bson.M {
+	return bson.M{
+		"userId":     userID,
+		"endedAt":    nil,
+		"lastSeenAt": bson.M{"$gt": now.Add(-sessionTimeout())},
+	}
+}
+
+// streamLimit returns how many streams a user may play at once, 0 for no
+// limit. Admins are not limited.
+func streamLimit(userID primitive.ObjectID) int64 {
+	var user models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		return config.AppConfig.MaxStreams
+	}
+	if user.Role == "admin" {
+		return 0
+	}
+	if limit, ok := config.AppConfig.PlanStreamLimits[user.Plan]; ok && user.Plan != "" {
+		return limit
+	}
+	return config.AppConfig.MaxStreams
+}
+
+// claimStreamSlot takes one of the user's streams for a session, or
+// refreshes the one it holds, and returns errStreamLimitReached if all are
+// taken. All slots of a user are in one document, so dropping timed out
+// sessions, checking the limit and adding the session is one atomic
+// update. When the limit is reached the filter does not match and the
+// upsert collides with the existing document.
+func claimStreamSlot(userID, sessionID primitive.ObjectID, now time.Time) error {
+	limit := streamLimit(userID)
+	if limit <= 0 {
+		return nil
+	}
+
+	others := bson.M{"$filter": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$sessions", bson.A{}}},
+		"cond": bson.M{"$and": bson.A{
+			bson.M{"$gt": bson.A{"$$this.lastSeenAt", now.Add(-sessionTimeout())}},
+			bson.M{"$ne": bson.A{"$$this.sessionId", sessionID}},
+		}},
+	}}
+	filter := bson.M{"_id": userID, "$expr": bson.M{"$lt": bson.A{bson.M{"$size": others}, limit}}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"sessions": bson.M{"$concatArrays": bson.A{others, bson.A{bson.M{"sessionId": sessionID, "lastSeenAt": now}}}},
+	}}}}
+
+	// A second try tells a full document from two first claims racing to
+	// create it
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err := streamSlotCollection.UpdateOne(context.Background(), filter, update, options.Update().SetUpsert(true))
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return errStreamLimitReached
+}
+
+// releaseStreamSlot frees the stream of a stopped session.
+func releaseStreamSlot(userID, sessionID primitive.ObjectID) error {
+	_, err := streamSlotCollection.UpdateOne(context.Background(),
+		bson.M{"_id": userID},
+		bson.M{"$pull": bson.M{"sessions": bson.M{"sessionId": sessionID}}})
+	return err
+}
+
+// requestDevice identifies the requesting device by the device ID of the
+// login, so reloading the player does not take another stream while a
+// second login does. The deviceName parameter only labels the session.
+func requestDevice(c *gin.Context) (string, string) {
+	deviceID := c.GetString("deviceId")
+	deviceName := c.Query("deviceName")
+	if deviceName == "" {
+		deviceName = c.Request.UserAgent()
+	}
+	return deviceID, deviceName
+}
+
+// startPlaybackSession opens a session for the requesting device, or moves
+// the device's active session to the new movie or episode.
+func startPlaybackSession(c *gin.Context, kind string, mediaID primitive.ObjectID) (models.PlaybackSession, error) {
+	var session models.PlaybackSession
+	userID, _ := c.Get("userId")
+	userObjectID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		return session, err
+	}
+	deviceID, deviceName := requestDevice(c)
+	now := time.Now()
+
+	filter := activeSessions(userObjectID, now)
+	filter["deviceId"] = deviceID
+	err = sessionCollection.FindOne(context.Background(), filter).Decode(&session)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return session, err
+	}
+	if err == mongo.ErrNoDocuments {
+		session = models.PlaybackSession{
+			ID:        primitive.NewObjectID(),
+			UserID:    userObjectID,
+			DeviceID:  deviceID,
+			StartedAt: now,
+		}
+	}
+	if err := claimStreamSlot(userObjectID, session.ID, now); err != nil {
+		return session, err
+	}
+
+	session.DeviceName = deviceName
+	session.IP = c.ClientIP()
+	session.Kind = kind
+	session.MediaID = mediaID
+	session.LastSeenAt = now
+	_, err = sessionCollection.ReplaceOne(context.Background(), bson.M{"_id": session.ID}, session, options.Replace().SetUpsert(true))
+	return session, err
+}
+
+// acquirePlaybackSession keeps the session of a signed stream URL alive.
+// A session that timed out is resumed if the limit allows it; a stopped
+// one stays stopped. It responds itself and returns false if the request
+// may not stream.
+func acquirePlaybackSession(c *gin.Context) bool {
+	sessionID, _ := c.Get("playbackSessionId")
+	objectID, err := primitive.ObjectIDFromHex(sessionID.(string))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid playback session"})
+		return false
+	}
+
+	var session models.PlaybackSession
+	if err := sessionCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&session); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid playback session"})
+		return false
+	}
+
+	err = touchPlaybackSession(session)
+	if errors.Is(err, errSessionEnded) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This playback session was stopped from another device"})
+		return false
+	}
+	if errors.Is(err, errStreamLimitReached) {
+		respondStreamLimit(c, session.UserID)
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update playback session"})
+		return false
+	}
+	return true
+}
+
+// touchPlaybackSession records a heartbeat of a session. A session that
+// timed out has lost its stream and takes a new one if the limit allows.
+func touchPlaybackSession(session models.PlaybackSession) error {
+	if session.EndedAt != nil {
+		return errSessionEnded
+	}
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) < sessionTouchInterval {
+		return nil
+	}
+	if err := claimStreamSlot(session.UserID, session.ID, now); err != nil {
+		return err
+	}
+	_, err := sessionCollection.UpdateOne(context.Background(),
+		bson.M{"_id": session.ID, "endedAt": nil},
+		bson.M{"$set": bson.M{"lastSeenAt": now}})
+	return err
+}
+
+// touchUserSession records a heartbeat for a session ID sent by a client,
+// if it is one of the user's sessions.
+func touchUserSession(userID primitive.ObjectID, sessionID string) error {
+	objectID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return err
+	}
+	var session models.PlaybackSession
+	if err := sessionCollection.FindOne(context.Background(), bson.M{"_id": objectID, "userId": userID}).Decode(&session); err != nil {
+		return err
+	}
+	return touchPlaybackSession(session)
+}
+
+// respondStreamLimit answers with the user's active sessions, so the
+// client can offer to stop one of them.
+func respondStreamLimit(c *gin.Context, userID primitive.ObjectID) {
+	sessions, _ := findActiveSessions(userID)
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":      "Maximum number of simultaneous streams reached",
+		"maxStreams": streamLimit(userID),
+		"sessions":   sessions,
+	})
+}
+
+func findActiveSessions(userID primitive.ObjectID) ([]models.PlaybackSession, error) {
+	sessions := []models.PlaybackSession{}
+	opts := options.Find().SetSort(bson.M{"lastSeenAt": -1})
+	cursor, err := sessionCollection.Find(context.Background(), activeSessions(userID, time.Now()), opts)
+	if err != nil {
+		return sessions, err
+	}
+	defer cursor.Close(context.Background())
+	err = cursor.All(context.Background(), &sessions)
+	return sessions, err
+}
+
+// GetPlaybackSessions lists the devices the user is streaming on.
+func GetPlaybackSessions(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userObjectID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	sessions, err := findActiveSessions(userObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch playback sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions, "maxStreams": streamLimit(userObjectID)})
+}
+
+// StopPlaybackSession ends one of the user's sessions, e.g. to free a
+// stream for another device. Its stream URLs stop working.
+func StopPlaybackSession(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userObjectID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	sessionID, err := primitive.ObjectIDFromHex(c.Param("sessionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	result, err := sessionCollection.UpdateOne(context.Background(),
+		bson.M{"_id": sessionID, "userId": userObjectID, "endedAt": nil},
+		bson.M{"$set": bson.M{"endedAt": time.Now()}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop playback session"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Playback session not found"})
+		return
+	}
+	if err := releaseStreamSlot(userObjectID, sessionID); err != nil {
+		log.Printf("user %s: failed to release stream of session %s: %v", userObjectID.Hex(), sessionID.Hex(), err)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Playback session stopped"})
+}
+
+// SetUserPlan assigns a user to a plan from PLAN_STREAM_LIMITS.
+func SetUserPlan(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	var req models.PlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, ok := config.AppConfig.PlanStreamLimits[req.Plan]; req.Plan != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown plan"})
+		return
+	}
+
+	update := bson.M{"$set": bson.M{"plan": req.Plan, "updatedAt": time.Now()}}
+	if req.Plan == "" {
+		update = bson.M{"$unset": bson.M{"plan": ""}, "$set": bson.M{"updatedAt": time.Now()}}
+	}
+	result, err := userCollection.UpdateOne(context.Background(), bson.M{"_id": objectID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"plan": req.Plan, "maxStreams": streamLimit(objectID)})
+}
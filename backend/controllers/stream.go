@@ -2,13 +2,19 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"log"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strconv"
+	"path"
+	"strings"
+	"time"
 
+	"stream4you/backend/config"
 	"stream4you/backend/database"
+	"stream4you/backend/media"
 	"stream4you/backend/models"
+	"stream4you/backend/storage"
+	"stream4you/backend/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
@@ -17,67 +23,349 @@ import (
 
 var movieCollection = database.DB.Collection("movies")
 
-func StreamVideo(c *gin.Context) {
-	movieID := c.Param("id")
-	objectID, err := primitive.ObjectIDFromHex(movieID)
+const (
+	hlsPlaylistType  = "application/vnd.apple.mpegurl"
+	dashManifestType = "application/dash+xml"
+)
+
+var packageContentTypes = map[string]string{
+	".m3u8": hlsPlaylistType,
+	".mp4":  "video/mp4",
+	".m4s":  "video/iso.segment",
+}
+
+// findStreamableMovie loads the movie in the :id parameter if the user may
+// watch it. It responds itself and returns false otherwise.
+func findStreamableMovie(c *gin.Context) (models.Movie, bool) {
+	var movie models.Movie
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return movie, false
+	}
+
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": objectID})).Decode(&movie)
+	if err != nil || (!isAdmin(c) && !isMovieAvailable(movie, time.Now())) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return movie, false
+	}
+	return movie, true
+}
+
+func StreamVideo(c *gin.Context) {
+	movie, ok := findStreamableMovie(c)
+	if !ok || !acquirePlaybackSession(c) {
 		return
 	}
 
-	// Fetch movie from database
-	var movie models.Movie
-	err = movieCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&movie)
+	serveVideoFile(c, movieVideoPath(movie), movieContentType(movie))
+}
+
+// StreamMasterPlaylist serves the HLS master playlist of a packaged movie,
+// with the movie's subtitle tracks and the user's preferred audio language
+// as the default audio. Movies without a package are redirected to the
+// progressive MP4.
+func StreamMasterPlaylist(c *gin.Context) {
+	movie, ok := findStreamableMovie(c)
+	if !ok || !acquirePlaybackSession(c) {
+		return
+	}
+	if movie.Package == nil {
+		c.Redirect(http.StatusTemporaryRedirect, strings.TrimSuffix(c.Request.URL.Path, "/master.m3u8"))
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-cache")
+	key := movie.Package.Prefix + "/master.m3u8"
+	audioLanguage := ""
+	if len(movie.Package.AudioTracks) > 1 {
+		audioLanguage = preferredAudioLanguage(c)
+	}
+	if len(movie.Subtitles) == 0 && audioLanguage == "" {
+		serveMedia(c, key, hlsPlaylistType, "Playlist not found")
+		return
+	}
+
+	// Subtitles can be added after packaging, so they are not in the file
+	master, err := readMedia(c.Request.Context(), key)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		log.Printf("media %s: %v", key, err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Playlist not found"})
 		return
 	}
+	if audioLanguage != "" {
+		master = media.SetDefaultAudio(master, audioLanguage)
+	}
+	c.Data(http.StatusOK, hlsPlaylistType, media.AddSubtitles(master, hlsSubtitles(movie)))
+}
 
-	// Use videoUrl from database or fallback to default path
-	var videoPath string
-	if movie.VideoURL != "" {
-		videoPath = movie.VideoURL
-	} else {
-		videoPath = filepath.Join("uploads", "videos", movieID+".mp4")
+// StreamDASHManifest serves the MPD of a packaged movie.
+func StreamDASHManifest(c *gin.Context) {
+	movie, ok := findStreamableMovie(c)
+	if !ok || !acquirePlaybackSession(c) {
+		return
 	}
-	
-	// Check if file exists
-	if _, err := os.Stat(videoPath); os.IsNotExist(err) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Video file not found"})
+	if movie.Package == nil || !movie.Package.DASH {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Manifest not found"})
 		return
 	}
 
-	// Get file info
-	file, err := os.Open(videoPath)
+	c.Header("Cache-Control", "private, no-cache")
+	serveMedia(c, movie.Package.Prefix+"/manifest.mpd", dashManifestType, "Manifest not found")
+}
+
+// preferredAudioLanguage returns the audio language the user chose in
+// their preferences, or "" without one.
+func preferredAudioLanguage(c *gin.Context) string {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open video file"})
+		return ""
+	}
+	var user models.User
+	if err := userCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user); err != nil {
+		return ""
+	}
+	return user.Preferences.AudioLanguage
+}
+
+// StreamPackageFile serves the rendition playlists and segments of a
+// packaged movie, for HLS and DASH alike. The package ID is part of the
+// URL, so files can be cached for good: a new package gets new URLs.
+func StreamPackageFile(c *gin.Context) {
+	movie, ok := findStreamableMovie(c)
+	if !ok || !acquirePlaybackSession(c) {
+		return
+	}
+	file := strings.TrimPrefix(c.Param("file"), "/")
+	if movie.Package == nil || c.Param("package") != movie.Package.JobID.Hex() || !storage.ValidKey(file) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
 		return
 	}
-	defer file.Close()
 
-	fileInfo, err := file.Stat()
+	contentType, ok := packageContentTypes[path.Ext(file)]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	serveMedia(c, movie.Package.Prefix+"/"+file, contentType, "File not found")
+}
+
+// movieVideoPath returns the movie's videoUrl or, without one, the default
+// location for its video.
+func movieVideoPath(movie models.Movie) string {
+	if movie.VideoURL != "" {
+		return movie.VideoURL
+	}
+	return "videos/" + movie.ID.Hex() + ".mp4"
+}
+
+func StreamEpisode(c *gin.Context) {
+	episodeID := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(episodeID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get file info"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid episode ID"})
 		return
 	}
 
-	// Set headers for video streaming
-	c.Header("Content-Type", "video/mp4")
-	c.Header("Accept-Ranges", "bytes")
-	c.Header("Content-Length", strconv.FormatInt(fileInfo.Size(), 10))
+	var episode models.Episode
+	err = episodeCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&episode)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+		return
+	}
+	if !acquirePlaybackSession(c) {
+		return
+	}
 
-	// Handle range requests for video seeking
-	rangeHeader := c.GetHeader("Range")
-	if rangeHeader != "" {
-		http.ServeContent(c.Writer, c.Request, filepath.Base(videoPath), fileInfo.ModTime(), file)
-	} else {
-		http.ServeFile(c.Writer, c.Request, videoPath)
+	videoPath := episode.VideoURL
+	if videoPath == "" {
+		videoPath = "episodes/" + episodeID + ".mp4"
 	}
+
+	serveVideoFile(c, videoPath, "")
+}
+
+// serveVideoFile streams a video from the media store with support for
+// range requests. Without a contentType, it follows from the extension.
+func serveVideoFile(c *gin.Context, videoPath, contentType string) {
+	key, ok := mediaKey(videoPath)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video file not found"})
+		return
+	}
+	if contentType == "" {
+		contentType = videoContentType(key)
+	}
+	serveMedia(c, key, contentType, "Video file not found")
+}
+
+// Stream formats a client can declare in GetVideoURL
+const (
+	streamFormatHLS         = "hls"
+	streamFormatDASH        = "dash"
+	streamFormatProgressive = "progressive"
+)
+
+// signedStreamURL returns the stream URL of a movie or episode for the
+// current user's playback session. The signed token in the path replaces
+// the bearer token, so players can fetch the URL and everything relative
+// to it directly.
+func signedStreamURL(c *gin.Context, kind string, session models.PlaybackSession) (string, time.Time) {
+	id := session.MediaID.Hex()
+	cfg := config.AppConfig
+	userID, _ := c.Get("userId")
+	role, _ := c.Get("role")
+	claims := utils.StreamClaims{
+		Kind:      kind,
+		ID:        id,
+		UserID:    userID.(string),
+		Role:      role.(string),
+		SessionID: session.ID.Hex(),
+		ExpiresAt: time.Now().Add(time.Duration(cfg.StreamURLExpiry) * time.Second),
+	}
+	if cfg.StreamURLBindIP {
+		claims.IP = c.ClientIP()
+	}
+
+	prefix := "/api/stream/"
+	if kind == utils.StreamKindEpisode {
+		prefix += "episodes/"
+	}
+	return prefix + id + "/t/" + utils.GenerateStreamToken(claims), claims.ExpiresAt
+}
+
+// startStreamSession starts the playback session a stream URL is issued
+// for. It responds itself and returns false if the user is at the stream
+// limit.
+func startStreamSession(c *gin.Context, kind string, mediaID primitive.ObjectID) (models.PlaybackSession, bool) {
+	session, err := startPlaybackSession(c, kind, mediaID)
+	if errors.Is(err, errStreamLimitReached) {
+		respondStreamLimit(c, session.UserID)
+		return session, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start playback session"})
+		return session, false
+	}
+	return session, true
 }
 
+// GetVideoURL returns the URL to play a movie from. Clients list the
+// formats they can play in the formats parameter, most preferred first
+// (e.g. formats=dash,progressive); the first one the movie is available in
+// is returned. Without the parameter HLS is preferred. The progressive MP4
+// is the fallback when no declared format is available.
 func GetVideoURL(c *gin.Context) {
-	movieID := c.Param("id")
-	videoURL := "/api/stream/" + movieID
-	c.JSON(http.StatusOK, gin.H{"videoUrl": videoURL})
+	movie, ok := findStreamableMovie(c)
+	if !ok {
+		return
+	}
+
+	session, ok := startStreamSession(c, utils.StreamKindMovie, movie.ID)
+	if !ok {
+		return
+	}
+	progressiveURL, expiresAt := signedStreamURL(c, utils.StreamKindMovie, session)
+	manifests := gin.H{}
+	if movie.Package != nil {
+		manifests[streamFormatHLS] = progressiveURL + "/master.m3u8"
+		if movie.Package.DASH {
+			manifests[streamFormatDASH] = progressiveURL + "/manifest.mpd"
+		}
+	}
+
+	formats := []string{streamFormatHLS, streamFormatProgressive}
+	if declared := c.Query("formats"); declared != "" {
+		formats = strings.Split(strings.ToLower(declared), ",")
+	}
+	response := gin.H{
+		"videoUrl":       progressiveURL,
+		"type":           streamFormatProgressive,
+		"progressiveUrl": progressiveURL,
+		"manifests":      manifests,
+		"expiresAt":      expiresAt,
+		"sessionId":      session.ID,
+	}
+	for _, format := range formats {
+		format = strings.TrimSpace(format)
+		if url, ok := manifests[format]; ok {
+			response["videoUrl"] = url
+			response["type"] = format
+			break
+		}
+		if format == streamFormatProgressive {
+			break
+		}
+	}
+
+	subtitles := []gin.H{}
+	for _, track := range movie.Subtitles {
+		subtitles = append(subtitles, gin.H{
+			"id":       track.ID,
+			"language": track.Language,
+			"label":    track.Label,
+			"forced":   track.Forced,
+			"sdh":      track.SDH,
+			"url":      progressiveURL + "/subtitles/" + track.ID + ".vtt",
+		})
+	}
+	response["subtitles"] = subtitles
+
+	audioTracks := []models.PackageAudioTrack{}
+	if movie.Package != nil && movie.Package.AudioTracks != nil {
+		audioTracks = movie.Package.AudioTracks
+	}
+	response["audioTracks"] = audioTracks
+
+	chapters := movie.Chapters
+	if chapters == nil {
+		chapters = []models.Chapter{}
+	}
+	markers := models.PlaybackMarkers{}
+	if movie.Markers != nil {
+		markers = *movie.Markers
+	}
+	response["chapters"] = chapters
+	response["markers"] = markers
+	response["preferredAudioLanguage"] = preferredAudioLanguage(c)
+
+	// Stores like S3 can serve the file directly, without the API in between.
+	// Opt-in, as the link is not tied to the playback session; it expires
+	// when a session without heartbeats would.
+	if key, ok := mediaKey(movieVideoPath(movie)); ok && config.AppConfig.DirectMediaURLs {
+		expires := min(time.Duration(config.AppConfig.PresignExpiry)*time.Second, sessionTimeout())
+		if url, expiresAt, ok := presignMedia(key, expires); ok {
+			response["directUrl"] = url
+			response["directUrlExpiresAt"] = expiresAt
+		}
+	}
+	c.JSON(http.StatusOK, response)
 }
 
+func GetEpisodeVideoURL(c *gin.Context) {
+	episodeID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid episode ID"})
+		return
+	}
+
+	// Only existing episodes may take one of the user's streams
+	var episode models.Episode
+	if err := episodeCollection.FindOne(context.Background(), bson.M{"_id": episodeID}).Decode(&episode); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+		return
+	}
+	count, err := seriesCollection.CountDocuments(context.Background(), bson.M{"_id": episode.SeriesID})
+	if err != nil || count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+		return
+	}
+
+	session, ok := startStreamSession(c, utils.StreamKindEpisode, episodeID)
+	if !ok {
+		return
+	}
+	videoURL, expiresAt := signedStreamURL(c, utils.StreamKindEpisode, session)
+	c.JSON(http.StatusOK, gin.H{"videoUrl": videoURL, "expiresAt": expiresAt, "sessionId": session.ID})
+}
@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"stream4you/backend/media"
+	"stream4you/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const subtitleMaxSize = 5 << 20
+
+var subtitleLanguagePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+func subtitleObjectKey(movieID primitive.ObjectID, trackID string) string {
+	return "subtitles/" + movieID.Hex() + "/" + trackID + ".vtt"
+}
+
+// UploadMovieSubtitle adds a subtitle track from an SRT or WebVTT file in
+// multipart field "file". Form fields: language (required), label, forced,
+// sdh and offset, the milliseconds to shift all cues by (negative values
+// make them appear earlier).
+func UploadMovieSubtitle(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, subtitleMaxSize+1<<20)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Subtitle file is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subtitle file is required"})
+		return
+	}
+	if header.Size > subtitleMaxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Subtitle file is too large"})
+		return
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	if format != "srt" && format != "vtt" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Subtitles must be an .srt or .vtt file"})
+		return
+	}
+
+	language := c.PostForm("language")
+	if !subtitleLanguagePattern.MatchString(language) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "language must be a language tag such as de or en-US"})
+		return
+	}
+	offset, err := strconv.ParseInt(c.DefaultPostForm("offset", "0"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a number of milliseconds"})
+		return
+	}
+	forced := c.PostForm("forced") == "true"
+	sdh := c.PostForm("sdh") == "true"
+	label := strings.TrimSpace(c.PostForm("label"))
+	if label == "" {
+		label = subtitleLabel(language, forced, sdh)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read subtitle file"})
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, subtitleMaxSize+1))
+	file.Close()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read subtitle file"})
+		return
+	}
+	vtt, err := media.ConvertToWebVTT(data, time.Duration(offset)*time.Millisecond)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	expected, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var current models.Movie
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": objectID})).Decode(&current)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	if expected != nil && *expected != current.Version {
+		respondMovieUpdateError(c, &versionConflictError{Current: current.Version}, true)
+		return
+	}
+	for _, existing := range current.Subtitles {
+		if existing.Label == label {
+			c.JSON(http.StatusConflict, gin.H{"error": "The movie already has a subtitle track with this label"})
+			return
+		}
+	}
+
+	track := models.SubtitleTrack{
+		ID:         primitive.NewObjectID().Hex(),
+		Language:   language,
+		Label:      label,
+		Forced:     forced,
+		SDH:        sdh,
+		Format:     format,
+		Offset:     offset,
+		UploadedAt: time.Now(),
+	}
+	track.Key = subtitleObjectKey(objectID, track.ID)
+	if err := mediaStore.Put(c.Request.Context(), track.Key, bytes.NewReader(vtt)); err != nil {
+		log.Printf("movie %s: failed to store subtitles: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store subtitles"})
+		return
+	}
+
+	subtitles := append(append([]models.SubtitleTrack{}, current.Subtitles...), track)
+	if !saveMovieAssets(c, objectID, current, bson.M{"subtitles": subtitles}, expected, gin.H{"subtitle": track}) {
+		// No revision refers to the file
+		if err := mediaStore.Delete(context.Background(), track.Key); err != nil {
+			log.Printf("movie %s: failed to remove subtitles %s: %v", objectID.Hex(), track.ID, err)
+		}
+	}
+}
+
+// subtitleLabel is the default label of a track, e.g. "EN (SDH)".
+func subtitleLabel(language string, forced, sdh bool) string {
+	label := strings.ToUpper(language)
+	if forced {
+		label += " (Forced)"
+	}
+	if sdh {
+		label += " (SDH)"
+	}
+	return label
+}
+
+func DeleteMovieSubtitle(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+	expected, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var current models.Movie
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": objectID})).Decode(&current)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+
+	subtitles := []models.SubtitleTrack{}
+	for _, existing := range current.Subtitles {
+		if existing.ID != c.Param("trackId") {
+			subtitles = append(subtitles, existing)
+		}
+	}
+	if len(subtitles) == len(current.Subtitles) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subtitle track not found"})
+		return
+	}
+
+	// Stored files are kept: revisions may still point to them
+	saveMovieAssets(c, objectID, current, bson.M{"subtitles": subtitles}, expected, gin.H{})
+}
+
+// findSubtitle returns the movie's track with the given ID.
+func findSubtitle(movie models.Movie, trackID string) (models.SubtitleTrack, bool) {
+	for _, track := range movie.Subtitles {
+		if track.ID == trackID {
+			return track, true
+		}
+	}
+	return models.SubtitleTrack{}, false
+}
+
+// StreamSubtitle serves a subtitle track as WebVTT (<track>.vtt) or, for
+// HLS, as a media playlist around it (<track>.m3u8). A track's file never
+// changes, a new upload gets a new ID.
+func StreamSubtitle(c *gin.Context) {
+	movie, ok := findStreamableMovie(c)
+	if !ok || !acquirePlaybackSession(c) {
+		return
+	}
+	file := c.Param("file")
+	ext := filepath.Ext(file)
+	track, ok := findSubtitle(movie, strings.TrimSuffix(file, ext))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subtitle track not found"})
+		return
+	}
+
+	switch ext {
+	case ".vtt":
+		c.Header("Cache-Control", "private, max-age=31536000, immutable")
+		serveMedia(c, track.Key, "text/vtt; charset=utf-8", "Subtitle track not found")
+	case ".m3u8":
+		if movie.Package == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Subtitle track not found"})
+			return
+		}
+		c.Header("Cache-Control", "private, no-cache")
+		c.Data(http.StatusOK, hlsPlaylistType, media.SubtitlePlaylist(movie.Package.Duration, track.ID+".vtt"))
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subtitle track not found"})
+	}
+}
+
+// hlsSubtitles returns the subtitle renditions for a movie's master
+// playlist.
+func hlsSubtitles(movie models.Movie) []media.HLSSubtitle {
+	subtitles := []media.HLSSubtitle{}
+	for _, track := range movie.Subtitles {
+		subtitles = append(subtitles, media.HLSSubtitle{
+			Name:     track.Label,
+			Language: track.Language,
+			Forced:   track.Forced,
+			SDH:      track.SDH,
+			URI:      "subtitles/" + track.ID + ".m3u8",
+		})
+	}
+	return subtitles
+}
+
+// removeSubtitleFiles deletes the stored subtitles of a purged movie.
+func removeSubtitleFiles(movie models.Movie) {
+	for _, track := range movie.Subtitles {
+		if err := mediaStore.Delete(context.Background(), track.Key); err != nil {
+			log.Printf("purge %s: failed to remove subtitles %s: %v", movie.ID.Hex(), track.ID, err)
+		}
+	}
+}
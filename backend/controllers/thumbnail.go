@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"time"
+
+	"stream4you/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const thumbnailTrackName = "thumbnails.vtt"
+
+var spriteNamePattern = regexp.MustCompile(`^sprite_\d{3,}\.jpg$`)
+
+// movieThumbnailsURL returns the URL of the movie's WebVTT thumbnail
+// track, or "" if its package has none.
+func movieThumbnailsURL(movie models.Movie) string {
+	if movie.Package == nil || !movie.Package.Thumbnails {
+		return ""
+	}
+	return "/api/stream/" + movie.ID.Hex() + "/thumbnails/" + movie.Package.JobID.Hex() + "/" + thumbnailTrackName
+}
+
+// StreamThumbnailFile serves the thumbnail track and sprite sheets of a
+// packaged movie. Like posters they need no stream URL, as players load
+// them before playback starts; the movie must still be available, or the
+// user an admin.
+func StreamThumbnailFile(c *gin.Context) {
+	movie, ok := findStreamableMovie(c)
+	if !ok {
+		return
+	}
+	file := c.Param("file")
+	if movie.Package == nil || !movie.Package.Thumbnails || c.Param("package") != movie.Package.JobID.Hex() ||
+		(file != thumbnailTrackName && !spriteNamePattern.MatchString(file)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Thumbnail not found"})
+		return
+	}
+
+	contentType := "image/jpeg"
+	if path.Ext(file) == ".vtt" {
+		contentType = "text/vtt; charset=utf-8"
+	}
+	// Shared caches must not keep the thumbnails of movies only admins see
+	visibility := "public"
+	if !isMovieAvailable(movie, time.Now()) {
+		visibility = "private"
+	}
+	c.Header("Cache-Control", visibility+", max-age=31536000, immutable")
+	serveMedia(c, movie.Package.Prefix+"/thumbnails/"+file, contentType, "Thumbnail not found")
+}
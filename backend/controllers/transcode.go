@@ -0,0 +1,511 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"stream4you/backend/config"
+	"stream4you/backend/database"
+	"stream4you/backend/events"
+	"stream4you/backend/media"
+	"stream4you/backend/models"
+	"stream4you/backend/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var transcodeJobCollection = database.DB.Collection("transcodeJobs")
+
+var errInvalidVideoPath = errors.New("movie has no video inside the media roots")
+
+// transcodeWake wakes an idle worker when a job is queued.
+var transcodeWake = make(chan struct{}, 1)
+
+const transcodePollInterval = 30 * time.Second
+
+func transcodeTimeout() time.Duration {
+	return time.Duration(config.AppConfig.TranscodeTimeout) * time.Minute
+}
+
+// enqueueTranscode queues packaging of the movie's current video. A job
+// that is still waiting for the same movie is reused.
+func enqueueTranscode(movieID, userID primitive.ObjectID) (models.TranscodeJob, error) {
+	var job models.TranscodeJob
+	var movie models.Movie
+	err := movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": movieID})).Decode(&movie)
+	if err == mongo.ErrNoDocuments {
+		return job, errMovieNotFound
+	}
+	if err != nil {
+		return job, err
+	}
+	source, ok := mediaKey(movieVideoPath(movie))
+	if !ok {
+		return job, errInvalidVideoPath
+	}
+
+	err = transcodeJobCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"movieId": movieID, "status": models.TranscodeStatusQueued},
+		bson.M{"$set": bson.M{"source": source}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&job)
+	if err == nil {
+		return job, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return job, err
+	}
+
+	job = models.TranscodeJob{
+		ID:        primitive.NewObjectID(),
+		MovieID:   movieID,
+		Source:    source,
+		Status:    models.TranscodeStatusQueued,
+		CreatedAt: time.Now(),
+		CreatedBy: userID,
+	}
+	if _, err := transcodeJobCollection.InsertOne(context.Background(), job); err != nil {
+		return job, err
+	}
+	movieCollection.UpdateOne(context.Background(), bson.M{"_id": movieID},
+		bson.M{"$set": bson.M{"streamStatus": models.TranscodeStatusQueued}})
+
+	select {
+	case transcodeWake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// claimTranscodeJob marks the oldest queued job as processing. The status
+// check makes this safe with several servers sharing the queue.
+func claimTranscodeJob() (models.TranscodeJob, bool) {
+	var job models.TranscodeJob
+	now := time.Now()
+	err := transcodeJobCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"status": models.TranscodeStatusQueued},
+		bson.M{"$set": bson.M{"status": models.TranscodeStatusProcessing, "startedAt": now}},
+		options.FindOneAndUpdate().SetSort(bson.M{"createdAt": 1}).SetReturnDocument(options.After),
+	).Decode(&job)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Println("Failed to claim transcode job:", err)
+		}
+		return job, false
+	}
+	movieCollection.UpdateOne(context.Background(), bson.M{"_id": job.MovieID},
+		bson.M{"$set": bson.M{"streamStatus": models.TranscodeStatusProcessing}})
+	return job, true
+}
+
+func runTranscodeJob(job models.TranscodeJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), transcodeTimeout())
+	defer cancel()
+
+	log.Printf("transcode %s: packaging movie %s from %s", job.ID.Hex(), job.MovieID.Hex(), job.Source)
+	pkg, files, err := packageMovie(ctx, job)
+	if err != nil {
+		log.Printf("transcode %s: %v", job.ID.Hex(), err)
+		removeMediaFiles(files)
+		finishTranscodeJob(job, bson.M{"status": models.TranscodeStatusFailed, "error": err.Error()})
+		return
+	}
+
+	// The document before the update still has the package being replaced
+	var movie models.Movie
+	err = movieCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": job.MovieID},
+		bson.M{"$set": bson.M{"package": pkg}},
+	).Decode(&movie)
+	if err != nil {
+		// The movie was purged in the meantime
+		removeMediaFiles(files)
+		finishTranscodeJob(job, bson.M{"status": models.TranscodeStatusFailed, "error": "movie not found"})
+		return
+	}
+
+	finishTranscodeJob(job, bson.M{"status": models.TranscodeStatusReady, "files": files})
+	removeStreamPackage(movie.Package)
+	log.Printf("transcode %s: movie %s packaged in %d renditions", job.ID.Hex(), job.MovieID.Hex(), len(pkg.Renditions))
+}
+
+// finishTranscodeJob stores the outcome of a job and, unless a newer job
+// is waiting, mirrors its status on the movie.
+func finishTranscodeJob(job models.TranscodeJob, set bson.M) {
+	set["finishedAt"] = time.Now()
+	if _, err := transcodeJobCollection.UpdateOne(context.Background(), bson.M{"_id": job.ID}, bson.M{"$set": set}); err != nil {
+		log.Printf("transcode %s: failed to save result: %v", job.ID.Hex(), err)
+	}
+
+	pending, _ := transcodeJobCollection.CountDocuments(context.Background(), bson.M{
+		"_id":     bson.M{"$ne": job.ID},
+		"movieId": job.MovieID,
+		"status":  bson.M{"$in": bson.A{models.TranscodeStatusQueued, models.TranscodeStatusProcessing}},
+	})
+	if pending == 0 {
+		movieCollection.UpdateOne(context.Background(), bson.M{"_id": job.MovieID},
+			bson.M{"$set": bson.M{"streamStatus": set["status"]}})
+	}
+}
+
+// packageMovie encodes every rendition and audio track, writes the HLS
+// master playlist, the DASH manifest and the thumbnail sprites and stores the result below
+// packages/<movie>/<job>. Both formats share the same segments. It returns the keys
+// stored so far, also when it fails.
+func packageMovie(ctx context.Context, job models.TranscodeJob) (models.StreamPackage, []string, error) {
+	cfg := config.AppConfig
+	pkg := models.StreamPackage{
+		JobID:  job.ID,
+		Prefix: "packages/" + job.MovieID.Hex() + "/" + job.ID.Hex(),
+	}
+
+	input, cleanup, err := mediaInputFile(ctx, job.Source)
+	if err != nil {
+		return pkg, nil, fmt.Errorf("failed to open source: %v", err)
+	}
+	defer cleanup()
+
+	probe, err := mediaProber.Probe(ctx, input)
+	if err != nil {
+		return pkg, nil, err
+	}
+	pkg.Duration = probe.Duration
+	pkg.HasAudio = probe.HasAudio
+
+	renditions, err := media.SelectRenditions(media.DefaultLadder, probe)
+	if err != nil {
+		return pkg, nil, fmt.Errorf("cannot transcode: %v", err)
+	}
+
+	workDir, err := os.MkdirTemp("", "transcode-*")
+	if err != nil {
+		return pkg, nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	audioRenditions := media.SelectAudioRenditions(probe.Audio)
+	steps := len(renditions) + len(audioRenditions) + 1 // the thumbnails last
+	audioBandwidth := 0
+	for _, rendition := range audioRenditions {
+		audioBandwidth = max(audioBandwidth, rendition.Bitrate*1000)
+	}
+	transcodeJobCollection.UpdateOne(context.Background(), bson.M{"_id": job.ID},
+		bson.M{"$set": bson.M{"renditions": steps}})
+	progress := func(done int) {
+		transcodeJobCollection.UpdateOne(context.Background(), bson.M{"_id": job.ID},
+			bson.M{"$set": bson.M{"progress": done}})
+	}
+
+	hlsBase := "hls/" + job.ID.Hex() + "/"
+	variants := []media.HLSVariant{}
+	representations := []media.DASHRepresentation{}
+	for i, rendition := range renditions {
+		dir := filepath.Join(workDir, rendition.Name)
+		if err := media.TranscodeHLS(ctx, cfg.FFmpegPath, input, dir, rendition); err != nil {
+			return pkg, nil, fmt.Errorf("rendition %s: %v", rendition.Name, err)
+		}
+		segments, err := renditionSegments(dir)
+		if err != nil {
+			return pkg, nil, fmt.Errorf("rendition %s: %v", rendition.Name, err)
+		}
+		progress(i + 1)
+
+		variants = append(variants, media.HLSVariant{
+			Rendition: rendition,
+			URI:       hlsBase + rendition.Name + "/index.m3u8",
+		})
+		representations = append(representations, media.DASHRepresentation{
+			Rendition: rendition,
+			Dir:       rendition.Name,
+			Segments:  segments,
+		})
+		pkg.Renditions = append(pkg.Renditions, models.PackageRendition{
+			Name:      rendition.Name,
+			Width:     rendition.Width,
+			Height:    rendition.Height,
+			Bandwidth: rendition.Bandwidth() + audioBandwidth,
+		})
+	}
+
+	audio := []media.HLSAudio{}
+	audioRepresentations := []media.DASHRepresentation{}
+	for i, rendition := range audioRenditions {
+		dir := filepath.Join(workDir, rendition.Name)
+		if err := media.TranscodeAudioHLS(ctx, cfg.FFmpegPath, input, dir, rendition); err != nil {
+			return pkg, nil, fmt.Errorf("audio %s: %v", rendition.Name, err)
+		}
+		segments, err := renditionSegments(dir)
+		if err != nil {
+			return pkg, nil, fmt.Errorf("audio %s: %v", rendition.Name, err)
+		}
+		progress(len(renditions) + i + 1)
+
+		audio = append(audio, media.HLSAudio{
+			Rendition: rendition,
+			URI:       hlsBase + rendition.Name + "/index.m3u8",
+		})
+		audioRepresentations = append(audioRepresentations, media.DASHRepresentation{
+			Audio:    rendition,
+			Dir:      rendition.Name,
+			Segments: segments,
+		})
+		pkg.AudioTracks = append(pkg.AudioTracks, models.PackageAudioTrack{
+			Name:     rendition.Name,
+			Language: rendition.Language,
+			Label:    rendition.Label,
+			Channels: rendition.Channels,
+			Default:  rendition.Default,
+		})
+	}
+
+	// Thumbnails are a nicety, the package is usable without them
+	thumbnailDir := filepath.Join(workDir, "thumbnails")
+	if err := writeThumbnails(ctx, input, thumbnailDir, probe); err != nil {
+		log.Printf("transcode %s: thumbnails: %v", job.ID.Hex(), err)
+		os.RemoveAll(thumbnailDir)
+	} else {
+		pkg.Thumbnails = true
+	}
+	progress(steps)
+
+	if err := os.WriteFile(filepath.Join(workDir, "master.m3u8"), media.MasterPlaylist(variants, audio), 0644); err != nil {
+		return pkg, nil, err
+	}
+	manifest, err := media.DASHManifest("dash/"+job.ID.Hex()+"/", probe.Duration, representations, audioRepresentations)
+	if err != nil {
+		return pkg, nil, err
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "manifest.mpd"), manifest, 0644); err != nil {
+		return pkg, nil, err
+	}
+	pkg.DASH = true
+
+	files, err := storePackageFiles(ctx, workDir, pkg.Prefix)
+	if err != nil {
+		return pkg, files, err
+	}
+	pkg.CreatedAt = time.Now()
+	return pkg, files, nil
+}
+
+// writeThumbnails writes the sprite sheets and the thumbnail track of the
+// video to dir.
+func writeThumbnails(ctx context.Context, input, dir string, probe media.ProbeResult) error {
+	width, height := media.ThumbnailSize(probe.Width, probe.Height)
+	sheets, err := media.GenerateSprites(ctx, config.AppConfig.FFmpegPath, input, dir, width, height)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "thumbnails.vtt"), media.ThumbnailTrack(probe.Duration, width, height, sheets), 0644)
+}
+
+// renditionSegments reads the segment durations from the playlist ffmpeg
+// wrote for a rendition.
+func renditionSegments(dir string) ([]float64, error) {
+	playlist, err := os.ReadFile(filepath.Join(dir, "index.m3u8"))
+	if err != nil {
+		return nil, err
+	}
+	return media.PlaylistSegments(playlist)
+}
+
+// mediaInputFile returns a local file ffmpeg can read the source from.
+// Sources in remote stores are downloaded first, as ffmpeg needs to seek.
+func mediaInputFile(ctx context.Context, key string) (string, func(), error) {
+	if local, ok := mediaStore.(*storage.LocalStore); ok {
+		path, err := local.FilePath(key)
+		return path, func() {}, err
+	}
+
+	body, err := mediaStore.OpenRange(ctx, key, 0, -1)
+	if err != nil {
+		return "", nil, err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp("", "transcode-source-*"+path.Ext(key))
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.Remove(tmp.Name()) }
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		cleanup()
+		return "", nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmp.Name(), cleanup, nil
+}
+
+// storePackageFiles copies everything below dir into the media store.
+func storePackageFiles(ctx context.Context, dir, prefix string) ([]string, error) {
+	files := []string{}
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		key := prefix + "/" + filepath.ToSlash(rel)
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := mediaStore.Put(ctx, key, f); err != nil {
+			return err
+		}
+		files = append(files, key)
+		return nil
+	})
+	return files, err
+}
+
+func removeMediaFiles(keys []string) {
+	for _, key := range keys {
+		if err := mediaStore.Delete(context.Background(), key); err != nil {
+			log.Printf("media %s: failed to remove: %v", key, err)
+		}
+	}
+}
+
+// removeStreamPackage deletes the stored files of a replaced or purged
+// package.
+func removeStreamPackage(pkg *models.StreamPackage) {
+	if pkg == nil {
+		return
+	}
+	var job models.TranscodeJob
+	if err := transcodeJobCollection.FindOne(context.Background(), bson.M{"_id": pkg.JobID}).Decode(&job); err != nil {
+		log.Printf("package %s: job not found, files are kept", pkg.Prefix)
+		return
+	}
+	removeMediaFiles(job.Files)
+	transcodeJobCollection.UpdateOne(context.Background(), bson.M{"_id": job.ID}, bson.M{"$unset": bson.M{"files": ""}})
+}
+
+// StartTranscoder starts the transcoding workers and queues a job whenever
+// a video is uploaded. Jobs left processing by a server that stopped are
+// queued again once they exceed the job timeout.
+func StartTranscoder() {
+	workers := int(config.AppConfig.TranscodeWorkers)
+	if workers <= 0 {
+		return
+	}
+
+	transcodeJobCollection.UpdateMany(
+		context.Background(),
+		bson.M{"status": models.TranscodeStatusProcessing, "startedAt": bson.M{"$lt": time.Now().Add(-transcodeTimeout())}},
+		bson.M{"$set": bson.M{"status": models.TranscodeStatusQueued}},
+	)
+
+	events.Subscribe(events.VideoUploaded, func(event events.Event) {
+		if _, err := enqueueTranscode(event.MovieID, primitive.NilObjectID); err != nil {
+			log.Printf("movie %s: failed to queue transcode: %v", event.MovieID.Hex(), err)
+		}
+	})
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			ticker := time.NewTicker(transcodePollInterval)
+			defer ticker.Stop()
+			for {
+				if job, ok := claimTranscodeJob(); ok {
+					runTranscodeJob(job)
+					continue
+				}
+				select {
+				case <-transcodeWake:
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+}
+
+// TranscodeMovie queues packaging of a movie's current video.
+func TranscodeMovie(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+	if config.AppConfig.TranscodeWorkers <= 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Transcoding is disabled"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	job, err := enqueueTranscode(objectID, userObjectID)
+	switch {
+	case errors.Is(err, errMovieNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	case errors.Is(err, errInvalidVideoPath):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue transcode job"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"job": job})
+}
+
+// GetTranscodeJobs returns a movie's packaging state and its latest jobs.
+func GetTranscodeJobs(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+
+	var movie models.Movie
+	if err := movieCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&movie); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(20)
+	cursor, err := transcodeJobCollection.Find(context.Background(), bson.M{"movieId": objectID}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transcode jobs"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	jobs := []models.TranscodeJob{}
+	if err := cursor.All(context.Background(), &jobs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode transcode jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"streamStatus": movie.StreamStatus,
+		"package":      movie.Package,
+		"jobs":         jobs,
+	})
+}
@@ -0,0 +1,269 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"stream4you/backend/config"
+	"stream4you/backend/database"
+	"stream4you/backend/models"
+	"stream4you/backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var genreTranslationCollection = database.DB.Collection("genreTranslations")
+
+// requestLanguage picks the response language from the lang query parameter
+// or the Accept-Language header and announces it in Content-Language.
+func requestLanguage(c *gin.Context) string {
+	lang := utils.NegotiateLanguage(
+		c.Query("lang"),
+		c.GetHeader("Accept-Language"),
+		config.AppConfig.SupportedLanguages,
+		config.AppConfig.DefaultLanguage,
+	)
+	c.Header("Content-Language", lang)
+	c.Header("Vary", "Accept-Language")
+	return lang
+}
+
+func isSupportedLanguage(lang string) bool {
+	for _, supported := range config.AppConfig.SupportedLanguages {
+		if lang == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// genreNames returns the display names of all genres in lang, keyed by the
+// stored genre name. Genres without a translation are missing.
+func genreNames(lang string) map[string]string {
+	names := map[string]string{}
+	cursor, err := genreTranslationCollection.Find(context.Background(), bson.M{"names." + lang: bson.M{"$exists": true}})
+	if err != nil {
+		return names
+	}
+	defer cursor.Close(context.Background())
+
+	var translations []models.GenreTranslation
+	cursor.All(context.Background(), &translations)
+	for _, translation := range translations {
+		names[translation.Genre] = translation.Names[lang]
+	}
+	return names
+}
+
+// localizeMovie replaces title, description and genres with their lang
+// versions. Anything not translated keeps the default language value.
+func localizeMovie(movie *models.Movie, lang string, genres map[string]string) {
+	for _, translation := range movie.Translations {
+		if translation.Language != lang {
+			continue
+		}
+		if translation.Title != "" {
+			movie.Title = translation.Title
+		}
+		if translation.Description != "" {
+			movie.Description = translation.Description
+		}
+	}
+
+	localized := make([]string, len(movie.Genre))
+	for i, genre := range movie.Genre {
+		localized[i] = genre
+		if name := genres[genre]; name != "" {
+			localized[i] = name
+		}
+	}
+	movie.Genre = localized
+}
+
+// SetMovieTranslation adds or replaces the title and description of a movie
+// in one language. The default language lives in the movie itself and is
+// edited with PUT/PATCH.
+func SetMovieTranslation(c *gin.Context) {
+	objectID, lang, ok := translationParams(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Title       string `json:"title" binding:"required"`
+		Description string `json:"description"`
+		Version     *int   `json:"version,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	translation := models.MovieTranslation{
+		Language:    lang,
+		Title:       strings.TrimSpace(req.Title),
+		Description: req.Description,
+	}
+	updateMovieTranslations(c, objectID, req.Version, func(translations []models.MovieTranslation) []models.MovieTranslation {
+		for i := range translations {
+			if translations[i].Language == lang {
+				translations[i] = translation
+				return translations
+			}
+		}
+		return append(translations, translation)
+	})
+}
+
+func DeleteMovieTranslation(c *gin.Context) {
+	objectID, lang, ok := translationParams(c)
+	if !ok {
+		return
+	}
+
+	updateMovieTranslations(c, objectID, nil, func(translations []models.MovieTranslation) []models.MovieTranslation {
+		kept := []models.MovieTranslation{}
+		for _, translation := range translations {
+			if translation.Language != lang {
+				kept = append(kept, translation)
+			}
+		}
+		return kept
+	})
+}
+
+func translationParams(c *gin.Context) (primitive.ObjectID, string, bool) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return objectID, "", false
+	}
+
+	lang := strings.ToLower(c.Param("lang"))
+	if !isSupportedLanguage(lang) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported language", "supported": config.AppConfig.SupportedLanguages})
+		return objectID, "", false
+	}
+	if lang == config.AppConfig.DefaultLanguage {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title and description in the default language are edited on the movie itself"})
+		return objectID, "", false
+	}
+	return objectID, lang, true
+}
+
+// updateMovieTranslations applies edit to the movie's translations and saves
+// them as a new revision. Without an expected version from the request, the
+// version read here guards against concurrent translation edits.
+func updateMovieTranslations(c *gin.Context, movieID primitive.ObjectID, version *int, edit func([]models.MovieTranslation) []models.MovieTranslation) {
+	expected, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	viaIfMatch := expected != nil
+	if expected == nil {
+		expected = version
+	}
+
+	var movie models.Movie
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": movieID})).Decode(&movie)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	if expected == nil {
+		expected = &movie.Version
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	translations := append([]models.MovieTranslation{}, movie.Translations...)
+	updated, changes, err := applyMovieUpdate(movieID, movieUpdate{
+		Set:             bson.M{"translations": edit(translations)},
+		ExpectedVersion: expected,
+		UserID:          userObjectID,
+		Action:          models.RevisionActionUpdate,
+	})
+	if err != nil {
+		respondMovieUpdateError(c, err, viaIfMatch)
+		return
+	}
+
+	setMovieETag(c, updated)
+	c.JSON(http.StatusOK, gin.H{"movie": updated, "changedFields": changedFieldNames(changes)})
+}
+
+func GetGenreTranslations(c *gin.Context) {
+	cursor, err := genreTranslationCollection.Find(context.Background(), bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch genre translations"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	translations := []models.GenreTranslation{}
+	if err := cursor.All(context.Background(), &translations); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode genre translations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"genres": translations})
+}
+
+// SetGenreTranslation replaces the display names of a genre. The body maps
+// languages to names; an empty map removes the genre's translations.
+func SetGenreTranslation(c *gin.Context) {
+	genre := strings.TrimSpace(c.Param("genre"))
+	if genre == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid genre"})
+		return
+	}
+
+	var req struct {
+		Names map[string]string `json:"names" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	names := map[string]string{}
+	for lang, name := range req.Names {
+		lang = strings.ToLower(lang)
+		if !isSupportedLanguage(lang) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported language " + lang, "supported": config.AppConfig.SupportedLanguages})
+			return
+		}
+		if name = strings.TrimSpace(name); name != "" {
+			names[lang] = name
+		}
+	}
+
+	if len(names) == 0 {
+		if _, err := genreTranslationCollection.DeleteOne(context.Background(), bson.M{"_id": genre}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update genre translations"})
+			return
+		}
+		c.JSON(http.StatusOK, models.GenreTranslation{Genre: genre, Names: names})
+		return
+	}
+
+	translation := models.GenreTranslation{Genre: genre, Names: names}
+	_, err := genreTranslationCollection.ReplaceOne(
+		context.Background(),
+		bson.M{"_id": genre},
+		translation,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update genre translations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, translation)
+}
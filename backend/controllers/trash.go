@@ -0,0 +1,337 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stream4you/backend/config"
+	"stream4you/backend/models"
+	"stream4you/backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const uploadsDir = "uploads"
+
+func GetTrash(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	skip := (page - 1) * limit
+
+	filter := bson.M{"deletedAt": bson.M{"$exists": true}}
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(limit)).SetSort(bson.M{"deletedAt": -1})
+
+	cursor, err := movieCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	movies := []models.Movie{}
+	if err := cursor.All(context.Background(), &movies); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode movies"})
+		return
+	}
+
+	total, _ := movieCollection.CountDocuments(context.Background(), filter)
+
+	items := make([]gin.H, 0, len(movies))
+	for _, movie := range movies {
+		items = append(items, gin.H{
+			"movie":   movie,
+			"purgeAt": movie.DeletedAt.Add(trashRetention()),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func RestoreMovie(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	err = setMovieTrashed(objectID, userObjectID, false)
+	if errors.Is(err, errMovieNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found in trash"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore movie"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Movie restored successfully"})
+}
+
+// PurgeMovie permanently removes a movie from the trash without waiting for
+// the retention period.
+func PurgeMovie(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+
+	var movie models.Movie
+	err = movieCollection.FindOne(context.Background(), bson.M{"_id": objectID, "deletedAt": bson.M{"$exists": true}}).Decode(&movie)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found in trash"})
+		return
+	}
+
+	if err := purgeMovie(movie); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge movie"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Movie permanently deleted"})
+}
+
+// purgeMovie deletes the movie document together with its reviews, its
+// place in curated collections, its stream package, the users' watch
+// progress and playback sessions, its revisions and uploads, and the media
+// files, subtitles and extras stored for it, including those only older
+// revisions refer to.
+func purgeMovie(movie models.Movie) error {
+	if _, err := reviewCollection.DeleteMany(context.Background(), bson.M{"movieId": movie.ID}); err != nil {
+		return err
+	}
+	if _, err := collectionCollection.UpdateMany(
+		context.Background(),
+		bson.M{"movieIds": movie.ID},
+		bson.M{"$pull": bson.M{"movieIds": movie.ID}},
+	); err != nil {
+		return err
+	}
+
+	history, paths, err := movieMediaHistory(movie)
+	if err != nil {
+		return err
+	}
+	removeMovieMedia(movie, paths)
+	removeUnusedImages(history)
+	removeSubtitleFiles(history)
+	removeStreamPackage(movie.Package)
+	removeMovieUploads(movie)
+	transcodeJobCollection.DeleteMany(context.Background(), bson.M{"movieId": movie.ID})
+	progressCollection.DeleteMany(context.Background(), bson.M{"movieId": movie.ID})
+	sessionCollection.DeleteMany(context.Background(), bson.M{"kind": utils.StreamKindMovie, "mediaId": movie.ID})
+	revisionCollection.DeleteMany(context.Background(), bson.M{"movieId": movie.ID})
+
+	_, err = movieCollection.DeleteOne(context.Background(), bson.M{"_id": movie.ID})
+	return err
+}
+
+// movieMediaHistory returns the movie with the images and subtitles of all
+// its revisions added, and the video and poster paths older revisions
+// refer to. Replaced files are kept for rollbacks until the movie is
+// purged.
+func movieMediaHistory(movie models.Movie) (models.Movie, []string, error) {
+	cursor, err := revisionCollection.Find(context.Background(), bson.M{"movieId": movie.ID})
+	if err != nil {
+		return movie, nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var revisions []struct {
+		Changes []struct {
+			Field string        `bson:"field"`
+			Old   bson.RawValue `bson:"old"`
+			New   bson.RawValue `bson:"new"`
+		} `bson:"changes"`
+	}
+	if err := cursor.All(context.Background(), &revisions); err != nil {
+		return movie, nil, err
+	}
+
+	history := movie
+	history.Images = append([]models.MovieImage{}, movie.Images...)
+	history.Subtitles = append([]models.SubtitleTrack{}, movie.Subtitles...)
+	paths := []string{}
+	for _, revision := range revisions {
+		for _, change := range revision.Changes {
+			for _, value := range []bson.RawValue{change.Old, change.New} {
+				if value.Type == 0 || value.Type == bson.TypeNull {
+					continue
+				}
+				switch change.Field {
+				case "videoUrl", "posterUrl":
+					if path, ok := value.StringValueOK(); ok {
+						paths = append(paths, path)
+					}
+				case "extras":
+					var extras []models.MovieExtra
+					if value.Unmarshal(&extras) == nil {
+						for _, extra := range extras {
+							paths = append(paths, extra.VideoURL)
+						}
+					}
+				case "images":
+					var images []models.MovieImage
+					if value.Unmarshal(&images) == nil {
+						history.Images = append(history.Images, images...)
+					}
+				case "subtitles":
+					var subtitles []models.SubtitleTrack
+					if value.Unmarshal(&subtitles) == nil {
+						history.Subtitles = append(history.Subtitles, subtitles...)
+					}
+				}
+			}
+		}
+	}
+	return history, paths, nil
+}
+
+// removeMovieMedia deletes the movie's media files and the older ones in
+// paths from the media store. Paths that do not map to a store key, such
+// as external URLs, and files another movie, extra or episode still uses
+// are skipped.
+func removeMovieMedia(movie models.Movie, paths []string) {
+	paths = append(paths,
+		movie.VideoURL,
+		movie.PosterURL,
+		"videos/"+movie.ID.Hex()+".mp4",
+	)
+	for _, extra := range movie.Extras {
+		paths = append(paths, extra.VideoURL)
+	}
+
+	inUse, err := mediaKeysInUse(movie.ID)
+	if err != nil {
+		log.Printf("purge %s: failed to find media in use, keeping files: %v", movie.ID.Hex(), err)
+		return
+	}
+	removed := map[string]bool{}
+	for _, path := range paths {
+		key, ok := mediaKey(path)
+		if path == "" || !ok || removed[key] || inUse[key] {
+			continue
+		}
+		removed[key] = true
+		if err := mediaStore.Delete(context.Background(), key); err != nil {
+			log.Printf("purge %s: failed to remove %s: %v", movie.ID.Hex(), key, err)
+		}
+	}
+}
+
+// mediaKeysInUse returns the store keys of the videos and posters of all
+// movies but the given one, of their extras and of all episodes. Paths are
+// compared as keys, as the same file may be stored as "videos/film.mp4",
+// "uploads/videos/film.mp4" or an absolute path below a media root.
+func mediaKeysInUse(except primitive.ObjectID) (map[string]bool, error) {
+	keys := map[string]bool{}
+	add := func(path string) {
+		if key, ok := mediaKey(path); path != "" && ok {
+			keys[key] = true
+		}
+	}
+
+	cursor, err := movieCollection.Find(context.Background(), bson.M{"_id": bson.M{"$ne": except}},
+		options.Find().SetProjection(bson.M{"videoUrl": 1, "posterUrl": 1, "extras.videoUrl": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var movies []models.Movie
+	err = cursor.All(context.Background(), &movies)
+	cursor.Close(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	for _, movie := range movies {
+		add(movie.VideoURL)
+		add(movie.PosterURL)
+		for _, extra := range movie.Extras {
+			add(extra.VideoURL)
+		}
+	}
+
+	cursor, err = episodeCollection.Find(context.Background(), bson.M{},
+		options.Find().SetProjection(bson.M{"videoUrl": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var episodes []models.Episode
+	err = cursor.All(context.Background(), &episodes)
+	cursor.Close(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	for _, episode := range episodes {
+		add(episode.VideoURL)
+	}
+	return keys, nil
+}
+
+func trashRetention() time.Duration {
+	return time.Duration(config.AppConfig.TrashRetentionDays) * 24 * time.Hour
+}
+
+// PurgeExpiredMovies permanently deletes every movie that has been in the
+// trash for longer than the retention period.
+func PurgeExpiredMovies() (int, error) {
+	cutoff := time.Now().Add(-trashRetention())
+	cursor, err := movieCollection.Find(context.Background(), bson.M{"deletedAt": bson.M{"$lte": cutoff}})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	var movies []models.Movie
+	if err := cursor.All(context.Background(), &movies); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, movie := range movies {
+		if err := purgeMovie(movie); err != nil {
+			log.Printf("purge %s: %v", movie.ID.Hex(), err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// StartTrashPurger runs PurgeExpiredMovies periodically in the background.
+func StartTrashPurger() {
+	interval := time.Duration(config.AppConfig.TrashPurgeInterval) * time.Minute
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			purged, err := PurgeExpiredMovies()
+			if err != nil {
+				log.Println("Trash purge failed:", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d movies from trash", purged)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,667 @@
+package controllers
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"stream4you/backend/config"
+	"stream4you/backend/database"
+	"stream4you/backend/events"
+	"stream4you/backend/models"
+	"stream4you/backend/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var uploadCollection = database.DB.Collection("uploads")
+
+// tus 1.0 protocol constants, see https://tus.io/protocols/resumable-upload
+const (
+	tusVersion             = "1.0.0"
+	tusExtensions          = "creation,creation-with-upload,termination,checksum,expiration"
+	tusChecksumAlgorithms  = "md5,sha1,sha256"
+	tusOffsetContentType   = "application/offset+octet-stream"
+	tusUploadPath          = "/api/admin/uploads/"
+	statusChecksumMismatch = 460
+)
+
+var uploadVideoExtensions = map[string]bool{
+	".mp4": true, ".m4v": true, ".mov": true, ".webm": true, ".mkv": true,
+}
+
+// uploadLocks serialises PATCH and DELETE requests per upload, so two
+// clients cannot write at the same offset. Entries are removed once an
+// upload is completed, deleted or expired.
+var uploadLocks sync.Map
+
+// uploadCleanupInterval is how often expired uploads are removed.
+const uploadCleanupInterval = 15 * time.Minute
+
+func lockUpload(id primitive.ObjectID) func() {
+	value, _ := uploadLocks.LoadOrStore(id, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// uploadExpiry is how long an unfinished upload is kept after its last
+// chunk, or 0 if uploads do not expire.
+func uploadExpiry() time.Duration {
+	return time.Duration(config.AppConfig.UploadExpiry) * time.Hour
+}
+
+// setUploadExpires announces when an unfinished upload expires.
+func setUploadExpires(c *gin.Context, upload models.Upload) {
+	if upload.Status != models.UploadStatusCompleted && uploadExpiry() > 0 {
+		c.Header("Upload-Expires", upload.UpdatedAt.Add(uploadExpiry()).UTC().Format(http.TimeFormat))
+	}
+}
+
+func uploadPartPath(id primitive.ObjectID) string {
+	return filepath.Join(uploadsDir, "tus", id.Hex()+".part")
+}
+
+// requireTus checks the Tus-Resumable header every request except OPTIONS
+// must carry.
+func requireTus(c *gin.Context) bool {
+	c.Header("Tus-Resumable", tusVersion)
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Unsupported tus version"})
+		return false
+	}
+	return true
+}
+
+// parseUploadMetadata decodes the Upload-Metadata header: comma separated
+// pairs of a key and a base64 encoded value.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		switch len(fields) {
+		case 0:
+			continue
+		case 1:
+			metadata[fields[0]] = ""
+		case 2:
+			value, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid metadata value for %q", fields[0])
+			}
+			metadata[fields[0]] = string(value)
+		default:
+			return nil, errors.New("invalid Upload-Metadata header")
+		}
+	}
+	return metadata, nil
+}
+
+// parseUploadChecksum reads an Upload-Checksum header ("sha1 <base64>").
+// It returns a nil hash when the header is absent.
+func parseUploadChecksum(header string) (hash.Hash, []byte, error) {
+	if header == "" {
+		return nil, nil, nil
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 2 {
+		return nil, nil, errors.New("invalid Upload-Checksum header")
+	}
+	expected, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, nil, errors.New("invalid Upload-Checksum header")
+	}
+
+	switch fields[0] {
+	case "md5":
+		return md5.New(), expected, nil
+	case "sha1":
+		return sha1.New(), expected, nil
+	case "sha256":
+		return sha256.New(), expected, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported checksum algorithm %q", fields[0])
+}
+
+func findUpload(c *gin.Context) (models.Upload, bool) {
+	var upload models.Upload
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return upload, false
+	}
+	if err := uploadCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&upload); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return upload, false
+	}
+	if uploadExpired(upload, time.Now()) {
+		c.JSON(http.StatusGone, gin.H{"error": "Upload has expired"})
+		return upload, false
+	}
+	return upload, true
+}
+
+func uploadExpired(upload models.Upload, now time.Time) bool {
+	return upload.Status != models.UploadStatusCompleted && uploadExpiry() > 0 &&
+		now.After(upload.UpdatedAt.Add(uploadExpiry()))
+}
+
+// TusOptions describes the server's tus capabilities.
+func TusOptions(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Header("Tus-Max-Size", strconv.FormatInt(config.AppConfig.UploadMaxSize, 10))
+	c.Header("Tus-Checksum-Algorithm", tusChecksumAlgorithms)
+	c.Status(http.StatusNoContent)
+}
+
+// CreateUpload starts a resumable video upload. The Upload-Metadata header
+// must name the movie ("movieId") and should carry the file name
+// ("filename"). With "extraId" the file becomes the video of that extra
+// instead of the movie's. Data sent with the request is stored right away
+// (creation-with-upload).
+func CreateUpload(c *gin.Context) {
+	if !requireTus(c) {
+		return
+	}
+
+	if c.GetHeader("Upload-Defer-Length") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Defer-Length is not supported"})
+		return
+	}
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Length header"})
+		return
+	}
+	if length > config.AppConfig.UploadMaxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Upload is too large"})
+		return
+	}
+
+	rawMetadata := c.GetHeader("Upload-Metadata")
+	metadata, err := parseUploadMetadata(rawMetadata)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	movieID, err := primitive.ObjectIDFromHex(metadata["movieId"])
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Metadata must contain a valid movieId"})
+		return
+	}
+	var movie models.Movie
+	err = movieCollection.FindOne(context.Background(), notDeleted(bson.M{"_id": movieID})).Decode(&movie)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	extraID := metadata["extraId"]
+	if extraID != "" && findExtra(movie, extraID) < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Extra not found"})
+		return
+	}
+
+	filename := filepath.Base(metadata["filename"])
+	if filename == "." || filename == string(filepath.Separator) {
+		filename = "video.mp4"
+	}
+	if !uploadVideoExtensions[strings.ToLower(filepath.Ext(filename))] {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Unsupported video format, use mp4, m4v, mov, webm or mkv"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	upload := models.Upload{
+		ID:        primitive.NewObjectID(),
+		MovieID:   movieID,
+		ExtraID:   extraID,
+		Filename:  filename,
+		Length:    length,
+		Metadata:  rawMetadata,
+		Status:    models.UploadStatusUploading,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		CreatedBy: userObjectID,
+	}
+
+	if err := os.MkdirAll(filepath.Dir(uploadPartPath(upload.ID)), 0755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload"})
+		return
+	}
+	file, err := os.Create(uploadPartPath(upload.ID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload"})
+		return
+	}
+	file.Close()
+
+	if _, err := uploadCollection.InsertOne(context.Background(), upload); err != nil {
+		os.Remove(uploadPartPath(upload.ID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload"})
+		return
+	}
+
+	c.Header("Location", tusUploadPath+upload.ID.Hex())
+	if c.ContentType() == tusOffsetContentType && c.Request.ContentLength != 0 {
+		unlock := lockUpload(upload.ID)
+		defer unlock()
+		if !writeUploadChunk(c, &upload, userObjectID) {
+			return
+		}
+	} else if length == 0 {
+		if err := completeUpload(&upload, userObjectID); err != nil {
+			respondUploadError(c, err)
+			return
+		}
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	setUploadExpires(c, upload)
+	c.Status(http.StatusCreated)
+}
+
+// HeadUpload reports how many bytes of an upload the server has.
+func HeadUpload(c *gin.Context) {
+	if !requireTus(c) {
+		return
+	}
+	upload, ok := findUpload(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	if upload.Metadata != "" {
+		c.Header("Upload-Metadata", upload.Metadata)
+	}
+	setUploadExpires(c, upload)
+	c.Status(http.StatusOK)
+}
+
+// PatchUpload appends a chunk at the offset given in Upload-Offset.
+func PatchUpload(c *gin.Context) {
+	if !requireTus(c) {
+		return
+	}
+	if c.ContentType() != tusOffsetContentType {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + tusOffsetContentType})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Offset header"})
+		return
+	}
+
+	upload, ok := findUpload(c)
+	if !ok {
+		return
+	}
+	unlock := lockUpload(upload.ID)
+	defer unlock()
+
+	// Re-read under the lock, another request may have just written
+	if err := uploadCollection.FindOne(context.Background(), bson.M{"_id": upload.ID}).Decode(&upload); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return
+	}
+	if offset != upload.Offset {
+		c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset does not match the current offset"})
+		return
+	}
+	if upload.Status == models.UploadStatusCompleted {
+		c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	// All bytes arrived before, but linking the video failed
+	if upload.Offset == upload.Length {
+		if err := completeUpload(&upload, userObjectID); err != nil {
+			respondUploadError(c, err)
+			return
+		}
+		c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	if !writeUploadChunk(c, &upload, userObjectID) {
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	setUploadExpires(c, upload)
+	c.Status(http.StatusNoContent)
+}
+
+// writeUploadChunk appends the request body to the upload. With an
+// Upload-Checksum header the chunk is only kept if it matches; without one,
+// whatever arrived before a dropped connection is kept so the client can
+// resume from there. It responds itself and returns false on errors.
+func writeUploadChunk(c *gin.Context, upload *models.Upload, userID primitive.ObjectID) bool {
+	checksum, expected, err := parseUploadChecksum(c.GetHeader("Upload-Checksum"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	path := uploadPartPath(upload.ID)
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open upload"})
+		return false
+	}
+	defer file.Close()
+
+	// Anything past the stored offset is left over from a failed request
+	if err := file.Truncate(upload.Offset); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write upload"})
+		return false
+	}
+	if _, err := file.Seek(upload.Offset, io.SeekStart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write upload"})
+		return false
+	}
+
+	var writer io.Writer = file
+	if checksum != nil {
+		writer = io.MultiWriter(file, checksum)
+	}
+	remaining := upload.Length - upload.Offset
+	written, copyErr := io.Copy(writer, io.LimitReader(c.Request.Body, remaining+1))
+
+	if written > remaining {
+		file.Truncate(upload.Offset)
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Chunk exceeds Upload-Length"})
+		return false
+	}
+	if checksum != nil && (copyErr != nil || string(checksum.Sum(nil)) != string(expected)) {
+		file.Truncate(upload.Offset)
+		if copyErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read chunk"})
+		} else {
+			c.JSON(statusChecksumMismatch, gin.H{"error": "Checksum mismatch"})
+		}
+		return false
+	}
+
+	upload.Offset += written
+	upload.UpdatedAt = time.Now()
+	_, err = uploadCollection.UpdateOne(context.Background(), bson.M{"_id": upload.ID},
+		bson.M{"$set": bson.M{"offset": upload.Offset, "updatedAt": upload.UpdatedAt}})
+	if err != nil {
+		file.Truncate(upload.Offset - written)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save upload progress"})
+		return false
+	}
+	if copyErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read chunk"})
+		return false
+	}
+
+	if upload.Offset == upload.Length {
+		file.Close()
+		if err := completeUpload(upload, userID); err != nil {
+			respondUploadError(c, err)
+			return false
+		}
+	}
+	return true
+}
+
+// completeUpload moves the finished file into the media store, points the
+// movie's videoUrl to it and announces the new video, which queues it for
+// packaging. Videos of extras are only linked to the extra. The previous
+// video is kept, as older revisions may still refer to it.
+// If the video cannot be linked for other reasons than the movie or extra
+// being gone, the upload stays at its full length and the client can
+// complete it by repeating the last PATCH request.
+func completeUpload(upload *models.Upload, userID primitive.ObjectID) error {
+	videoKey := uploadVideoKey(*upload)
+	// Without a part file, an earlier attempt has already stored the video
+	partPath := uploadPartPath(upload.ID)
+	_, err := os.Stat(partPath)
+	switch {
+	case err == nil:
+		if err := storage.PutFile(context.Background(), mediaStore, videoKey, partPath); err != nil {
+			return err
+		}
+	case !os.IsNotExist(err):
+		return err
+	case !storage.Exists(context.Background(), mediaStore, videoKey):
+		return fmt.Errorf("upload %s: file is missing", upload.ID.Hex())
+	}
+
+	var movie models.Movie
+	if upload.ExtraID != "" {
+		err = attachExtraVideo(upload.MovieID, upload.ExtraID, videoKey, userID)
+	} else {
+		movie, _, err = applyMovieUpdate(upload.MovieID, movieUpdate{
+			Set:    bson.M{"videoUrl": videoKey},
+			UserID: userID,
+			Action: models.RevisionActionUpdate,
+		})
+	}
+	if errors.Is(err, errMovieNotFound) || errors.Is(err, errExtraNotFound) {
+		// Nothing to attach the video to any more
+		mediaStore.Delete(context.Background(), videoKey)
+		os.Remove(partPath)
+		uploadCollection.DeleteOne(context.Background(), bson.M{"_id": upload.ID})
+		uploadLocks.Delete(upload.ID)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	upload.Status = models.UploadStatusCompleted
+	upload.VideoURL = videoKey
+	upload.CompletedAt = &now
+	_, err = uploadCollection.UpdateOne(context.Background(), bson.M{"_id": upload.ID}, bson.M{"$set": bson.M{
+		"status":      upload.Status,
+		"videoUrl":    upload.VideoURL,
+		"completedAt": now,
+		"updatedAt":   now,
+	}})
+	if err != nil {
+		log.Printf("upload %s: failed to mark as completed: %v", upload.ID.Hex(), err)
+	}
+	// Requests for a completed upload write nothing and need no lock
+	uploadLocks.Delete(upload.ID)
+	if upload.ExtraID == "" {
+		publishMovieEvent(events.VideoUploaded, movie)
+	}
+	return nil
+}
+
+// uploadVideoKey is where the file of a finished upload is stored.
+func uploadVideoKey(upload models.Upload) string {
+	ext := strings.ToLower(filepath.Ext(upload.Filename))
+	if upload.ExtraID != "" {
+		return "videos/extras/" + upload.MovieID.Hex() + "-" + upload.ID.Hex() + ext
+	}
+	return "videos/" + upload.MovieID.Hex() + "-" + upload.ID.Hex() + ext
+}
+
+func respondUploadError(c *gin.Context, err error) {
+	if errors.Is(err, errMovieNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	if errors.Is(err, errExtraNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Extra not found"})
+		return
+	}
+	log.Printf("upload: failed to complete: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete upload"})
+}
+
+// DeleteUpload terminates an upload and removes its data. For completed
+// uploads only the record is removed; the video stays linked to the movie.
+func DeleteUpload(c *gin.Context) {
+	if !requireTus(c) {
+		return
+	}
+	upload, ok := findUpload(c)
+	if !ok {
+		return
+	}
+	unlock := lockUpload(upload.ID)
+	defer unlock()
+
+	if upload.Status != models.UploadStatusCompleted {
+		if err := os.Remove(uploadPartPath(upload.ID)); err != nil && !os.IsNotExist(err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete upload"})
+			return
+		}
+	}
+	if _, err := uploadCollection.DeleteOne(context.Background(), bson.M{"_id": upload.ID}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete upload"})
+		return
+	}
+	uploadLocks.Delete(upload.ID)
+
+	c.Status(http.StatusNoContent)
+}
+
+// removeMovieUploads deletes the uploads of a purged movie with their part
+// files. Videos of unfinished uploads that were stored but never linked
+// are removed too; linked ones are left to removeMovieMedia.
+func removeMovieUploads(movie models.Movie) {
+	cursor, err := uploadCollection.Find(context.Background(), bson.M{"movieId": movie.ID})
+	if err != nil {
+		log.Printf("purge %s: failed to find uploads: %v", movie.ID.Hex(), err)
+		return
+	}
+	var uploads []models.Upload
+	err = cursor.All(context.Background(), &uploads)
+	cursor.Close(context.Background())
+	if err != nil {
+		log.Printf("purge %s: failed to read uploads: %v", movie.ID.Hex(), err)
+		return
+	}
+
+	for _, upload := range uploads {
+		if upload.Status != models.UploadStatusCompleted {
+			os.Remove(uploadPartPath(upload.ID))
+			mediaStore.Delete(context.Background(), uploadVideoKey(upload))
+		}
+		uploadLocks.Delete(upload.ID)
+	}
+	uploadCollection.DeleteMany(context.Background(), bson.M{"movieId": movie.ID})
+}
+
+// StartUploadCleaner periodically removes unfinished uploads that have
+// expired, with their part files.
+func StartUploadCleaner() {
+	if uploadExpiry() <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(uploadCleanupInterval)
+		defer ticker.Stop()
+		for {
+			removed, err := RemoveExpiredUploads()
+			if err != nil {
+				log.Println("Upload cleanup failed:", err)
+			} else if removed > 0 {
+				log.Printf("Removed %d expired uploads", removed)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// RemoveExpiredUploads deletes unfinished uploads whose last chunk is
+// older than the upload expiry and returns how many were removed.
+func RemoveExpiredUploads() (int, error) {
+	cursor, err := uploadCollection.Find(context.Background(), bson.M{
+		"status":    bson.M{"$ne": models.UploadStatusCompleted},
+		"updatedAt": bson.M{"$lt": time.Now().Add(-uploadExpiry())},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var uploads []models.Upload
+	err = cursor.All(context.Background(), &uploads)
+	cursor.Close(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, upload := range uploads {
+		if removeExpiredUpload(upload.ID) {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// removeExpiredUpload deletes an upload unless a request has resumed or
+// completed it in the meantime.
+func removeExpiredUpload(id primitive.ObjectID) bool {
+	unlock := lockUpload(id)
+	defer unlock()
+
+	var upload models.Upload
+	if err := uploadCollection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&upload); err != nil {
+		uploadLocks.Delete(id)
+		return false
+	}
+	if !uploadExpired(upload, time.Now()) {
+		return false
+	}
+
+	if err := os.Remove(uploadPartPath(upload.ID)); err != nil && !os.IsNotExist(err) {
+		log.Printf("upload %s: failed to remove part file: %v", upload.ID.Hex(), err)
+		return false
+	}
+	// A complete upload may have stored its video before linking failed;
+	// keep it if the movie refers to it anyway
+	if upload.Offset == upload.Length {
+		videoKey := uploadVideoKey(upload)
+		linked, err := movieCollection.CountDocuments(context.Background(), bson.M{
+			"$or": bson.A{bson.M{"videoUrl": videoKey}, bson.M{"extras.videoUrl": videoKey}},
+		})
+		if err == nil && linked == 0 {
+			mediaStore.Delete(context.Background(), videoKey)
+		}
+	}
+	if _, err := uploadCollection.DeleteOne(context.Background(), bson.M{"_id": upload.ID}); err != nil {
+		log.Printf("upload %s: failed to delete expired upload: %v", upload.ID.Hex(), err)
+		return false
+	}
+	uploadLocks.Delete(upload.ID)
+	return true
+}
@@ -1,46 +1,46 @@
-package database
-
-import (
-	"context"
-	"log"
-	"time"
-
-	"stream4you/backend/config"
-
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
-)
-
-var DB *mongo.Database
-var Client *mongo.Client
-
-func Connect() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	clientOptions := options.Client().ApplyURI(config.AppConfig.MongoURI)
-	client, err := mongo.Connect(ctx, clientOptions)
-	if err != nil {
-		return err
-	}
-
-	// Ping the database
-	if err := client.Ping(ctx, nil); err != nil {
-		return err
-	}
-
-	Client = client
-	DB = client.Database(config.AppConfig.DatabaseName)
-
-	log.Println("Connected to MongoDB!")
-	return nil
-}
-
-func Disconnect() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	return Client.Disconnect(ctx)
-}
-
-
-
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"stream4you/backend/config"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var DB *mongo.Database
+var Client *mongo.Client
+
+func Connect() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	clientOptions := options.Client().ApplyURI(config.AppConfig.MongoURI)
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return err
+	}
+
+	// Ping the database
+	if err := client.Ping(ctx, nil); err != nil {
+		return err
+	}
+
+	Client = client
+	DB = client.Database(config.AppConfig.DatabaseName)
+
+	log.Println("Connected to MongoDB!")
+	return nil
+}
+
+func Disconnect() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return Client.Disconnect(ctx)
+}
+
+
+
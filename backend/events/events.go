@@ -0,0 +1,51 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MovieLive     = "movie.live"           // a movie became available to users
+	MovieExpired  = "movie.expired"        // a movie's availability window ended
+	VideoUploaded = "movie.video_uploaded" // a new video file was uploaded for a movie
+)
+
+type Event struct {
+	Type    string             `json:"type"`
+	MovieID primitive.ObjectID `json:"movieId"`
+	Title   string             `json:"title"`
+	At      time.Time          `json:"at"`
+}
+
+type Handler func(Event)
+
+var (
+	mu       sync.RWMutex
+	handlers = map[string][]Handler{}
+)
+
+// Subscribe registers handler for events of the given type.
+func Subscribe(eventType string, handler Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handlers[eventType] = append(handlers[eventType], handler)
+}
+
+// Publish logs the event and calls its handlers in their own goroutines, so
+// a slow handler cannot hold up the publisher.
+func Publish(event Event) {
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	log.Printf("event %s: movie %s (%s)", event.Type, event.MovieID.Hex(), event.Title)
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, handler := range handlers[event.Type] {
+		go handler(event)
+	}
+}
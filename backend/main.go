@@ -1,61 +1,77 @@
-package main
-
-import (
-	"log"
-	"os"
-
-	"stream4you/backend/config"
-	"stream4you/backend/database"
-	"stream4you/backend/routes"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-)
-
-func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
-	}
-
-	// Initialize database
-	if err := database.Connect(); err != nil {
-		log.Fatal("Failed to connect to database:", err)
-	}
-
-	// Setup Gin router
-	router := gin.Default()
-
-	// CORS configuration
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-	}))
-
-	// API routes
-	api := router.Group("/api")
-	{
-		routes.SetupAuthRoutes(api)
-		routes.SetupMovieRoutes(api)
-		routes.SetupStreamRoutes(api)
-		routes.SetupRecommendationRoutes(api)
-	}
-
-	// Get port from environment or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	log.Printf("Server starting on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
-	}
-}
-
-
-
+package main
+
+import (
+	"log"
+	"os"
+
+	"stream4you/backend/config"
+	"stream4you/backend/controllers"
+	"stream4you/backend/database"
+	"stream4you/backend/routes"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	// Initialize database
+	if err := database.Connect(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	// Background jobs
+	controllers.StartTrashPurger()
+	controllers.StartUploadCleaner()
+	controllers.StartPublicationScheduler()
+	controllers.StartTranscoder()
+	controllers.StartAnalytics()
+
+	// Setup Gin router
+	router := gin.Default()
+	if err := router.SetTrustedProxies(config.AppConfig.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
+	// CORS configuration
+	router.Use(cors.New(cors.Config{
+		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:3000"},
+		AllowMethods:     []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", "Tus-Resumable", "Upload-Length", "Upload-Offset", "Upload-Metadata", "Upload-Checksum"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "Location", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size", "Upload-Offset", "Upload-Length"},
+		AllowCredentials: true,
+	}))
+
+	// API routes
+	api := router.Group("/api")
+	{
+		routes.SetupAuthRoutes(api)
+		routes.SetupMovieRoutes(api)
+		routes.SetupSeriesRoutes(api)
+		routes.SetupPeopleRoutes(api)
+		routes.SetupCollectionRoutes(api)
+		routes.SetupStreamRoutes(api)
+		routes.SetupImageRoutes(api)
+		routes.SetupRecommendationRoutes(api)
+		routes.SetupAdminRoutes(api)
+	}
+
+	// Get port from environment or use default
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	log.Printf("Server starting on port %s", port)
+	if err := router.Run(":" + port); err != nil {
+		log.Fatal("Failed to start server:", err)
+	}
+}
+
+
+
@@ -0,0 +1,183 @@
+package media
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+)
+
+// DASHTimescale is the timescale of the segment timelines, in ticks per
+// second.
+const DASHTimescale = 1000
+
+// DASHRepresentation is a rendition entry of an MPD. Segments are the
+// durations from the rendition's HLS playlist, as both formats share the
+// same fragmented MP4 files.
+type DASHRepresentation struct {
+	Rendition Rendition      // video renditions only
+	Audio     AudioRendition // audio renditions only
+	Dir       string         // directory of the rendition, relative to the base URL
+	Segments  []float64
+}
+
+type mpd struct {
+	XMLName                   xml.Name  `xml:"MPD"`
+	Xmlns                     string    `xml:"xmlns,attr"`
+	Profiles                  string    `xml:"profiles,attr"`
+	Type                      string    `xml:"type,attr"`
+	MediaPresentationDuration string    `xml:"mediaPresentationDuration,attr"`
+	MinBufferTime             string    `xml:"minBufferTime,attr"`
+	BaseURL                   string    `xml:"BaseURL,omitempty"`
+	Period                    mpdPeriod `xml:"Period"`
+}
+
+type mpdPeriod struct {
+	ID             string             `xml:"id,attr"`
+	Start          string             `xml:"start,attr"`
+	AdaptationSets []mpdAdaptationSet `xml:"AdaptationSet"`
+}
+
+type mpdAdaptationSet struct {
+	ID               int                 `xml:"id,attr"`
+	ContentType      string              `xml:"contentType,attr"`
+	MimeType         string              `xml:"mimeType,attr"`
+	Codecs           string              `xml:"codecs,attr"`
+	Lang             string              `xml:"lang,attr,omitempty"`
+	SegmentAlignment bool                `xml:"segmentAlignment,attr"`
+	StartWithSAP     int                 `xml:"startWithSAP,attr"`
+	AudioChannels    *mpdDescriptor      `xml:"AudioChannelConfiguration,omitempty"`
+	Label            string              `xml:"Label,omitempty"`
+	Role             *mpdDescriptor      `xml:"Role,omitempty"`
+	Representations  []mpdRepresentation `xml:"Representation"`
+}
+
+type mpdDescriptor struct {
+	SchemeIDURI string `xml:"schemeIdUri,attr"`
+	Value       string `xml:"value,attr"`
+}
+
+type mpdRepresentation struct {
+	ID                string             `xml:"id,attr"`
+	Bandwidth         int                `xml:"bandwidth,attr"`
+	Width             int                `xml:"width,attr,omitempty"`
+	Height            int                `xml:"height,attr,omitempty"`
+	AudioSamplingRate int                `xml:"audioSamplingRate,attr,omitempty"`
+	SegmentTemplate   mpdSegmentTemplate `xml:"SegmentTemplate"`
+}
+
+type mpdSegmentTemplate struct {
+	Timescale      int            `xml:"timescale,attr"`
+	Initialization string         `xml:"initialization,attr"`
+	Media          string         `xml:"media,attr"`
+	StartNumber    int            `xml:"startNumber,attr"`
+	Timeline       []mpdTimelineS `xml:"SegmentTimeline>S"`
+}
+
+type mpdTimelineS struct {
+	T *int64 `xml:"t,attr"`
+	D int64  `xml:"d,attr"`
+	R int    `xml:"r,attr,omitempty"`
+}
+
+// DASHManifest renders a static MPD for video representations and audio
+// representations, each audio track in an adaptation set of its own.
+// Segment URLs are relative to baseURL.
+func DASHManifest(baseURL string, duration float64, video []DASHRepresentation, audio []DASHRepresentation) ([]byte, error) {
+	manifest := mpd{
+		Xmlns:                     "urn:mpeg:dash:schema:mpd:2011",
+		Profiles:                  "urn:mpeg:dash:profile:isoff-live:2011",
+		Type:                      "static",
+		MediaPresentationDuration: dashDuration(duration),
+		MinBufferTime:             dashDuration(HLSSegmentDuration),
+		BaseURL:                   baseURL,
+		Period:                    mpdPeriod{ID: "0", Start: "PT0S"},
+	}
+
+	videoSet := mpdAdaptationSet{
+		ID:               0,
+		ContentType:      "video",
+		MimeType:         "video/mp4",
+		Codecs:           VideoCodecString,
+		SegmentAlignment: true,
+		StartWithSAP:     1,
+	}
+	for _, representation := range video {
+		videoSet.Representations = append(videoSet.Representations, mpdRepresentation{
+			ID:              representation.Rendition.Name,
+			Bandwidth:       representation.Rendition.Bandwidth(),
+			Width:           representation.Rendition.Width,
+			Height:          representation.Rendition.Height,
+			SegmentTemplate: segmentTemplate(representation),
+		})
+	}
+	manifest.Period.AdaptationSets = append(manifest.Period.AdaptationSets, videoSet)
+
+	for i, representation := range audio {
+		role := "alternate"
+		if representation.Audio.Default {
+			role = "main"
+		}
+		manifest.Period.AdaptationSets = append(manifest.Period.AdaptationSets, mpdAdaptationSet{
+			ID:               1 + i,
+			ContentType:      "audio",
+			MimeType:         "audio/mp4",
+			Codecs:           AudioCodecString,
+			Lang:             representation.Audio.Language,
+			SegmentAlignment: true,
+			StartWithSAP:     1,
+			AudioChannels: &mpdDescriptor{
+				SchemeIDURI: "urn:mpeg:dash:23003:3:audio_channel_configuration:2011",
+				Value:       fmt.Sprint(representation.Audio.Channels),
+			},
+			Label: representation.Audio.Label,
+			Role:  &mpdDescriptor{SchemeIDURI: "urn:mpeg:dash:role:2011", Value: role},
+			Representations: []mpdRepresentation{{
+				ID:                representation.Audio.Name,
+				Bandwidth:         representation.Audio.Bitrate * 1000,
+				AudioSamplingRate: AudioSampleRate,
+				SegmentTemplate:   segmentTemplate(representation),
+			}},
+		})
+	}
+
+	output, err := xml.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(output, '\n')...), nil
+}
+
+// segmentTemplate describes the segments of a rendition with a timeline,
+// so the shorter last segment and small deviations are exact. Equal
+// durations in a row are folded into one entry.
+func segmentTemplate(representation DASHRepresentation) mpdSegmentTemplate {
+	template := mpdSegmentTemplate{
+		Timescale:      DASHTimescale,
+		Initialization: representation.Dir + "/init.mp4",
+		Media:          representation.Dir + "/seg_$Number%05d$.m4s",
+		StartNumber:    0,
+	}
+	var total float64
+	var start int64
+	for _, duration := range representation.Segments {
+		// Rounding the running total keeps rounding errors from adding up
+		total += duration
+		end := int64(math.Round(total * DASHTimescale))
+		d := end - start
+		if last := len(template.Timeline) - 1; last >= 0 && template.Timeline[last].D == d {
+			template.Timeline[last].R++
+		} else if last < 0 {
+			t := int64(0)
+			template.Timeline = append(template.Timeline, mpdTimelineS{T: &t, D: d})
+		} else {
+			template.Timeline = append(template.Timeline, mpdTimelineS{D: d})
+		}
+		start = end
+	}
+	return template
+}
+
+// dashDuration formats seconds as an xs:duration.
+func dashDuration(seconds float64) string {
+	return fmt.Sprintf("PT%.3fS", seconds)
+}
@@ -0,0 +1,120 @@
+package media
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDASHManifest(t *testing.T) {
+	video := []DASHRepresentation{
+		{Rendition: testVariants[0].Rendition, Dir: "720p", Segments: []float64{6, 6, 6, 2.5}},
+		{Rendition: testVariants[1].Rendition, Dir: "360p", Segments: []float64{6, 6, 6, 2.5}},
+	}
+	audio := []DASHRepresentation{
+		{Audio: testAudio[0].Rendition, Dir: "audio_0", Segments: []float64{6.006, 5.994, 6, 2.5}},
+		{Audio: testAudio[2].Rendition, Dir: "audio_2", Segments: []float64{6.006, 5.994, 6, 2.5}},
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="static" mediaPresentationDuration="PT20.500S" minBufferTime="PT6.000S">
+  <BaseURL>dash/job/</BaseURL>
+  <Period id="0" start="PT0S">
+    <AdaptationSet id="0" contentType="video" mimeType="video/mp4" codecs="avc1.4d4028" segmentAlignment="true" startWithSAP="1">
+      <Representation id="720p" bandwidth="2996000" width="1280" height="720">
+        <SegmentTemplate timescale="1000" initialization="720p/init.mp4" media="720p/seg_$Number%05d$.m4s" startNumber="0">
+          <SegmentTimeline>
+            <S t="0" d="6000" r="2"></S>
+            <S d="2500"></S>
+          </SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+      <Representation id="360p" bandwidth="856000" width="640" height="360">
+        <SegmentTemplate timescale="1000" initialization="360p/init.mp4" media="360p/seg_$Number%05d$.m4s" startNumber="0">
+          <SegmentTimeline>
+            <S t="0" d="6000" r="2"></S>
+            <S d="2500"></S>
+          </SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" contentType="audio" mimeType="audio/mp4" codecs="mp4a.40.2" lang="de" segmentAlignment="true" startWithSAP="1">
+      <AudioChannelConfiguration schemeIdUri="urn:mpeg:dash:23003:3:audio_channel_configuration:2011" value="6"></AudioChannelConfiguration>
+      <Label>Deutsch</Label>
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"></Role>
+      <Representation id="audio_0" bandwidth="384000" audioSamplingRate="48000">
+        <SegmentTemplate timescale="1000" initialization="audio_0/init.mp4" media="audio_0/seg_$Number%05d$.m4s" startNumber="0">
+          <SegmentTimeline>
+            <S t="0" d="6006"></S>
+            <S d="5994"></S>
+            <S d="6000"></S>
+            <S d="2500"></S>
+          </SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" contentType="audio" mimeType="audio/mp4" codecs="mp4a.40.2" lang="en" segmentAlignment="true" startWithSAP="1">
+      <AudioChannelConfiguration schemeIdUri="urn:mpeg:dash:23003:3:audio_channel_configuration:2011" value="2"></AudioChannelConfiguration>
+      <Label>Director&#39;s &#34;Cut&#34;</Label>
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="alternate"></Role>
+      <Representation id="audio_2" bandwidth="128000" audioSamplingRate="48000">
+        <SegmentTemplate timescale="1000" initialization="audio_2/init.mp4" media="audio_2/seg_$Number%05d$.m4s" startNumber="0">
+          <SegmentTimeline>
+            <S t="0" d="6006"></S>
+            <S d="5994"></S>
+            <S d="6000"></S>
+            <S d="2500"></S>
+          </SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	got, err := DASHManifest("dash/job/", 20.5, video, audio)
+	if err != nil {
+		t.Fatalf("DASHManifest: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSegmentTimeline(t *testing.T) {
+	zero := int64(0)
+	tests := []struct {
+		name     string
+		segments []float64
+		want     []mpdTimelineS
+	}{
+		{
+			name: "no segments",
+		},
+		{
+			name:     "equal segments are folded",
+			segments: []float64{2.002, 2.002, 2.002},
+			want:     []mpdTimelineS{{T: &zero, D: 2002, R: 2}},
+		},
+		{
+			name:     "rounding errors do not add up",
+			segments: []float64{1.0 / 3, 1.0 / 3, 1.0 / 3},
+			want:     []mpdTimelineS{{T: &zero, D: 333}, {D: 334}, {D: 333}},
+		},
+		{
+			name:     "shorter last segment",
+			segments: []float64{6, 6, 0.04},
+			want:     []mpdTimelineS{{T: &zero, D: 6000, R: 1}, {D: 40}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := segmentTemplate(DASHRepresentation{Dir: "720p", Segments: tt.segments})
+			if template.Initialization != "720p/init.mp4" || template.Media != "720p/seg_$Number%05d$.m4s" {
+				t.Errorf("initialization %q, media %q", template.Initialization, template.Media)
+			}
+			if !reflect.DeepEqual(template.Timeline, tt.want) {
+				t.Errorf("timeline %+v, want %+v", template.Timeline, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// HLSSegmentDuration is the target segment length in seconds.
+const HLSSegmentDuration = 6
+
+// Codec strings of the renditions: H.264 Main profile level 4.0 and AAC-LC.
+const (
+	VideoCodecString = "avc1.4d4028"
+	AudioCodecString = "mp4a.40.2"
+)
+
+// Each audio stream is encoded once, as a rendition of its own shared by
+// all video renditions. DASH players expect audio and video in separate
+// tracks.
+const AudioSampleRate = 48000
+
+// Bitrates of the audio renditions, in kbit/s
+const (
+	StereoAudioBitrate   = 128
+	SurroundAudioBitrate = 384
+)
+
+// AudioRendition is an audio stream of the source encoded to AAC, in
+// stereo or, for sources with more than two channels, in 5.1.
+type AudioRendition struct {
+	Name     string // directory of the rendition, e.g. "audio_0"
+	Stream   int    // index among the source's audio streams
+	Language string
+	Label    string
+	Channels int
+	Bitrate  int // kbit/s
+	Default  bool
+}
+
+// SelectAudioRenditions returns a rendition for every audio stream. The
+// stream flagged as default in the source is the default rendition, or
+// the first one if none is flagged.
+func SelectAudioRenditions(streams []AudioStream) []AudioRendition {
+	renditions := []AudioRendition{}
+	labels := map[string]int{}
+	defaultIndex := 0
+	for i, stream := range streams {
+		if stream.Default {
+			defaultIndex = i
+			break
+		}
+	}
+	for i, stream := range streams {
+		rendition := AudioRendition{
+			Name:     fmt.Sprintf("audio_%d", stream.Index),
+			Stream:   stream.Index,
+			Language: stream.Language,
+			Channels: 2,
+			Bitrate:  StereoAudioBitrate,
+			Default:  i == defaultIndex,
+		}
+		if stream.Channels > 2 {
+			rendition.Channels = 6
+			rendition.Bitrate = SurroundAudioBitrate
+		}
+		rendition.Label = audioLabel(stream, rendition.Channels)
+		// Names of the renditions in a group must be unique
+		labels[rendition.Label]++
+		if n := labels[rendition.Label]; n > 1 {
+			rendition.Label += fmt.Sprintf(" (%d)", n)
+		}
+		renditions = append(renditions, rendition)
+	}
+	return renditions
+}
+
+// audioLabel is the title of the stream or else a label like "DE 5.1".
+func audioLabel(stream AudioStream, channels int) string {
+	if title := strings.TrimSpace(stream.Title); title != "" {
+		return title
+	}
+	label := strings.ToUpper(stream.Language)
+	if stream.Language == "und" {
+		label = fmt.Sprintf("Audio %d", stream.Index+1)
+	}
+	if channels == 6 {
+		label += " 5.1"
+	}
+	return label
+}
+
+// Rendition is one video quality level of an adaptive stream. The bitrate
+// is in kbit/s.
+type Rendition struct {
+	Name         string
+	Width        int
+	Height       int
+	VideoBitrate int
+}
+
+// DefaultLadder is the rendition ladder used for packaging, from the
+// highest to the lowest quality. Width and height are the box each
+// rendition is fitted into.
+var DefaultLadder = []Rendition{
+	{Name: "1080p", Width: 1920, Height: 1080, VideoBitrate: 5000},
+	{Name: "720p", Width: 1280, Height: 720, VideoBitrate: 2800},
+	{Name: "480p", Width: 854, Height: 480, VideoBitrate: 1400},
+	{Name: "360p", Width: 640, Height: 360, VideoBitrate: 800},
+}
+
+// ErrNoVideoStream is returned for sources without a video stream of
+// known size.
+var ErrNoVideoStream = errors.New("source has no video stream with known dimensions")
+
+// SelectRenditions fits the ladder to the source's aspect ratio, so a
+// 1920x800 source gets a 1920x800 "1080p" rendition, and drops renditions
+// that would upscale. A source smaller than every rendition gets the lowest
+// one at its own size.
+func SelectRenditions(ladder []Rendition, source ProbeResult) ([]Rendition, error) {
+	if source.VideoCodec == "" || source.Width <= 0 || source.Height <= 0 {
+		return nil, ErrNoVideoStream
+	}
+	selected := []Rendition{}
+	for _, rendition := range ladder {
+		scale := math.Min(float64(rendition.Width)/float64(source.Width), float64(rendition.Height)/float64(source.Height))
+		if scale > 1 {
+			continue
+		}
+		rendition.Width = even(float64(source.Width) * scale)
+		rendition.Height = even(float64(source.Height) * scale)
+		selected = append(selected, rendition)
+	}
+	if len(selected) == 0 && len(ladder) > 0 {
+		lowest := ladder[len(ladder)-1]
+		lowest.Width, lowest.Height = even(float64(source.Width)), even(float64(source.Height))
+		selected = append(selected, lowest)
+	}
+	return selected, nil
+}
+
+// even rounds to the nearest even size, as H.264 with 4:2:0 chroma needs.
+func even(size float64) int {
+	rounded := int(math.Round(size/2)) * 2
+	if rounded < 2 {
+		return 2
+	}
+	return rounded
+}
+
+// Bandwidth is the peak video bitrate of a rendition in bit/s.
+func (r Rendition) Bandwidth() int {
+	return r.maxrate() * 1000
+}
+
+func (r Rendition) maxrate() int {
+	return r.VideoBitrate * 107 / 100
+}
+
+// TranscodeHLS encodes the video of input into one rendition below dir: a
+// VOD playlist index.m3u8, the fragmented MP4 init segment init.mp4 and
+// media segments seg_00000.m4s and up. Keyframes are forced at every
+// segment boundary, so the segments of all renditions line up and players
+// can switch between them.
+func TranscodeHLS(ctx context.Context, ffmpegPath, input, dir string, rendition Rendition) error {
+	return runHLS(ctx, ffmpegPath, input, dir,
+		"-map", "0:v:0", "-an",
+		"-vf", fmt.Sprintf("scale=%d:%d", rendition.Width, rendition.Height),
+		"-c:v", "libx264", "-preset", "veryfast", "-profile:v", "main", "-level:v", "4.0", "-pix_fmt", "yuv420p",
+		"-b:v", fmt.Sprintf("%dk", rendition.VideoBitrate),
+		"-maxrate", fmt.Sprintf("%dk", rendition.maxrate()),
+		"-bufsize", fmt.Sprintf("%dk", rendition.VideoBitrate*3/2),
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", HLSSegmentDuration), "-sc_threshold", "0",
+	)
+}
+
+// TranscodeAudioHLS encodes an audio stream of input to AAC, laid out below
+// dir like a video rendition.
+func TranscodeAudioHLS(ctx context.Context, ffmpegPath, input, dir string, rendition AudioRendition) error {
+	return runHLS(ctx, ffmpegPath, input, dir,
+		"-map", fmt.Sprintf("0:a:%d", rendition.Stream), "-vn",
+		"-c:a", "aac", "-b:a", fmt.Sprintf("%dk", rendition.Bitrate),
+		"-ac", fmt.Sprint(rendition.Channels), "-ar", fmt.Sprint(AudioSampleRate),
+	)
+}
+
+func runHLS(ctx context.Context, ffmpegPath, input, dir string, codecArgs ...string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	args := []string{"-hide_banner", "-loglevel", "error", "-y", "-i", input}
+	args = append(args, codecArgs...)
+	args = append(args,
+		"-f", "hls",
+		"-hls_time", fmt.Sprint(HLSSegmentDuration),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_type", "fmp4",
+		"-hls_flags", "independent_segments",
+		"-hls_fmp4_init_filename", "init.mp4",
+		"-hls_segment_filename", filepath.Join(dir, "seg_%05d.m4s"),
+		filepath.Join(dir, "index.m3u8"),
+	)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpegPath, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return exec.ErrNotFound
+		}
+		return fmt.Errorf("ffmpeg: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
+
+// PlaylistSegments returns the segment durations of a media playlist, in
+// seconds.
+func PlaylistSegments(playlist []byte) ([]float64, error) {
+	durations := []float64{}
+	for _, line := range strings.Split(string(playlist), "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "#EXTINF:")
+		if !ok {
+			continue
+		}
+		value, _, _ = strings.Cut(value, ",")
+		duration, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid segment duration %q", value)
+		}
+		durations = append(durations, duration)
+	}
+	if len(durations) == 0 {
+		return nil, errors.New("playlist has no segments")
+	}
+	return durations, nil
+}
+
+// HLSVariant is a video rendition entry of a master playlist.
+type HLSVariant struct {
+	Rendition Rendition
+	URI       string
+}
+
+// HLSAudio is an audio rendition entry of a master playlist.
+type HLSAudio struct {
+	Rendition AudioRendition
+	URI       string
+}
+
+// AudioGroupID is the group the audio renditions of a master playlist
+// belong to.
+const AudioGroupID = "audio"
+
+// MasterPlaylist renders the HLS master playlist for variants. With audio
+// renditions, every variant refers to them; its bandwidth includes the
+// largest of them.
+func MasterPlaylist(variants []HLSVariant, audio []HLSAudio) []byte {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-INDEPENDENT-SEGMENTS\n")
+	audioBandwidth, codecs, audioGroup := 0, VideoCodecString, ""
+	for _, track := range audio {
+		fmt.Fprintf(&b, "#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"%s\",NAME=\"%s\",LANGUAGE=\"%s\",DEFAULT=%s,AUTOSELECT=YES,CHANNELS=\"%d\",URI=\"%s\"\n",
+			AudioGroupID, quotedString(track.Rendition.Label), quotedString(track.Rendition.Language),
+			yesNo(track.Rendition.Default), track.Rendition.Channels, track.URI)
+		audioBandwidth = max(audioBandwidth, track.Rendition.Bitrate*1000)
+	}
+	if len(audio) > 0 {
+		codecs += "," + AudioCodecString
+		audioGroup = ",AUDIO=\"" + AudioGroupID + "\""
+	}
+	for _, variant := range variants {
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,AVERAGE-BANDWIDTH=%d,RESOLUTION=%dx%d,CODECS=\"%s\"%s\n",
+			variant.Rendition.Bandwidth()+audioBandwidth,
+			variant.Rendition.VideoBitrate*1000+audioBandwidth,
+			variant.Rendition.Width, variant.Rendition.Height,
+			codecs, audioGroup,
+		)
+		b.WriteString(variant.URI + "\n")
+	}
+	return []byte(b.String())
+}
+
+// SetDefaultAudio makes the first audio rendition in language the default
+// of a master playlist. A language tag also matches renditions of its
+// primary language, so "de" matches "de-AT". Playlists without a rendition
+// in language are returned unchanged.
+func SetDefaultAudio(master []byte, language string) []byte {
+	lines := strings.SplitAfter(string(master), "\n")
+	preferred := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "#EXT-X-MEDIA:TYPE=AUDIO,") && languageMatches(hlsAttribute(line, "LANGUAGE"), language) {
+			preferred = i
+			break
+		}
+	}
+	if preferred < 0 {
+		return master
+	}
+
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "#EXT-X-MEDIA:TYPE=AUDIO,") {
+			continue
+		}
+		value := "DEFAULT=NO"
+		if i == preferred {
+			value = "DEFAULT=YES"
+		}
+		lines[i] = strings.Replace(strings.Replace(line, "DEFAULT=YES", value, 1), "DEFAULT=NO", value, 1)
+	}
+	return []byte(strings.Join(lines, ""))
+}
+
+// hlsAttribute returns the value of a quoted-string attribute of a tag.
+func hlsAttribute(line, name string) string {
+	_, value, ok := strings.Cut(line, ","+name+"=\"")
+	if !ok {
+		return ""
+	}
+	value, _, _ = strings.Cut(value, "\"")
+	return value
+}
+
+func languageMatches(language, preferred string) bool {
+	language, preferred = strings.ToLower(language), strings.ToLower(preferred)
+	if language == "" || preferred == "" {
+		return false
+	}
+	primary, _, _ := strings.Cut(language, "-")
+	return language == preferred || primary == preferred
+}
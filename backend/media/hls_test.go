@@ -0,0 +1,258 @@
+package media
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectRenditions(t *testing.T) {
+	tests := []struct {
+		name   string
+		source ProbeResult
+		want   []Rendition
+	}{
+		{
+			name:   "full HD",
+			source: ProbeResult{VideoCodec: "h264", Width: 1920, Height: 1080},
+			want: []Rendition{
+				{Name: "1080p", Width: 1920, Height: 1080, VideoBitrate: 5000},
+				{Name: "720p", Width: 1280, Height: 720, VideoBitrate: 2800},
+				{Name: "480p", Width: 854, Height: 480, VideoBitrate: 1400},
+				{Name: "360p", Width: 640, Height: 360, VideoBitrate: 800},
+			},
+		},
+		{
+			name:   "cinemascope",
+			source: ProbeResult{VideoCodec: "h264", Width: 1920, Height: 800},
+			want: []Rendition{
+				{Name: "1080p", Width: 1920, Height: 800, VideoBitrate: 5000},
+				{Name: "720p", Width: 1280, Height: 534, VideoBitrate: 2800},
+				{Name: "480p", Width: 854, Height: 356, VideoBitrate: 1400},
+				{Name: "360p", Width: 640, Height: 266, VideoBitrate: 800},
+			},
+		},
+		{
+			name:   "no upscaling",
+			source: ProbeResult{VideoCodec: "h264", Width: 1280, Height: 720},
+			want: []Rendition{
+				{Name: "720p", Width: 1280, Height: 720, VideoBitrate: 2800},
+				{Name: "480p", Width: 854, Height: 480, VideoBitrate: 1400},
+				{Name: "360p", Width: 640, Height: 360, VideoBitrate: 800},
+			},
+		},
+		{
+			name:   "smaller than every rendition",
+			source: ProbeResult{VideoCodec: "mpeg4", Width: 321, Height: 241},
+			want: []Rendition{
+				{Name: "360p", Width: 322, Height: 242, VideoBitrate: 800},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectRenditions(DefaultLadder, tt.source)
+			if err != nil {
+				t.Fatalf("SelectRenditions: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectRenditionsWithoutVideo(t *testing.T) {
+	sources := map[string]ProbeResult{
+		"audio only":     {HasAudio: true, Duration: 210},
+		"no width":       {VideoCodec: "h264", Height: 1080},
+		"no height":      {VideoCodec: "h264", Width: 1920},
+		"no video codec": {Width: 1920, Height: 1080},
+	}
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			renditions, err := SelectRenditions(DefaultLadder, source)
+			if !errors.Is(err, ErrNoVideoStream) {
+				t.Errorf("error %v, want ErrNoVideoStream", err)
+			}
+			if len(renditions) != 0 {
+				t.Errorf("got renditions %+v", renditions)
+			}
+		})
+	}
+}
+
+func TestSelectAudioRenditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		streams []AudioStream
+		want    []AudioRendition
+	}{
+		{
+			name: "no audio",
+			want: []AudioRendition{},
+		},
+		{
+			name: "flagged default, surround and duplicate labels",
+			streams: []AudioStream{
+				{Index: 0, Language: "de", Channels: 6},
+				{Index: 1, Language: "en", Channels: 2, Default: true},
+				{Index: 2, Language: "en", Channels: 1},
+				{Index: 3, Language: "und", Channels: 2},
+				{Index: 4, Language: "en", Channels: 2, Title: "  Kommentar  "},
+			},
+			want: []AudioRendition{
+				{Name: "audio_0", Stream: 0, Language: "de", Label: "DE 5.1", Channels: 6, Bitrate: 384},
+				{Name: "audio_1", Stream: 1, Language: "en", Label: "EN", Channels: 2, Bitrate: 128, Default: true},
+				{Name: "audio_2", Stream: 2, Language: "en", Label: "EN (2)", Channels: 2, Bitrate: 128},
+				{Name: "audio_3", Stream: 3, Language: "und", Label: "Audio 4", Channels: 2, Bitrate: 128},
+				{Name: "audio_4", Stream: 4, Language: "en", Label: "Kommentar", Channels: 2, Bitrate: 128},
+			},
+		},
+		{
+			name: "first stream without a flagged default",
+			streams: []AudioStream{
+				{Index: 0, Language: "fr", Channels: 8},
+				{Index: 1, Language: "fr", Channels: 2},
+			},
+			want: []AudioRendition{
+				{Name: "audio_0", Stream: 0, Language: "fr", Label: "FR 5.1", Channels: 6, Bitrate: 384, Default: true},
+				{Name: "audio_1", Stream: 1, Language: "fr", Label: "FR", Channels: 2, Bitrate: 128},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SelectAudioRenditions(tt.streams); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testVariants and testAudio are a package with two renditions and three
+// audio tracks, the German one in 5.1.
+var testVariants = []HLSVariant{
+	{Rendition: Rendition{Name: "720p", Width: 1280, Height: 720, VideoBitrate: 2800}, URI: "hls/job/720p/index.m3u8"},
+	{Rendition: Rendition{Name: "360p", Width: 640, Height: 360, VideoBitrate: 800}, URI: "hls/job/360p/index.m3u8"},
+}
+
+var testAudio = []HLSAudio{
+	{Rendition: AudioRendition{Name: "audio_0", Language: "de", Label: "Deutsch", Channels: 6, Bitrate: 384, Default: true}, URI: "hls/job/audio_0/index.m3u8"},
+	{Rendition: AudioRendition{Name: "audio_1", Stream: 1, Language: "en", Label: "EN", Channels: 2, Bitrate: 128}, URI: "hls/job/audio_1/index.m3u8"},
+	{Rendition: AudioRendition{Name: "audio_2", Stream: 2, Language: "en", Label: "Director's \"Cut\"", Channels: 2, Bitrate: 128}, URI: "hls/job/audio_2/index.m3u8"},
+}
+
+var testSubtitles = []HLSSubtitle{
+	{Name: "Deutsch (erzwungen)", Language: "de", Forced: true, URI: "subtitles/de-forced.m3u8"},
+	{Name: "English SDH", Language: "en", SDH: true, URI: "subtitles/en-sdh.m3u8"},
+}
+
+const testMaster = `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="audio",NAME="Deutsch",LANGUAGE="de",DEFAULT=YES,AUTOSELECT=YES,CHANNELS="6",URI="hls/job/audio_0/index.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="audio",NAME="EN",LANGUAGE="en",DEFAULT=NO,AUTOSELECT=YES,CHANNELS="2",URI="hls/job/audio_1/index.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="audio",NAME="Director's 'Cut'",LANGUAGE="en",DEFAULT=NO,AUTOSELECT=YES,CHANNELS="2",URI="hls/job/audio_2/index.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=3380000,AVERAGE-BANDWIDTH=3184000,RESOLUTION=1280x720,CODECS="avc1.4d4028,mp4a.40.2",AUDIO="audio"
+hls/job/720p/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=1240000,AVERAGE-BANDWIDTH=1184000,RESOLUTION=640x360,CODECS="avc1.4d4028,mp4a.40.2",AUDIO="audio"
+hls/job/360p/index.m3u8
+`
+
+func TestMasterPlaylist(t *testing.T) {
+	tests := []struct {
+		name     string
+		variants []HLSVariant
+		audio    []HLSAudio
+		want     string
+	}{
+		{
+			name:     "video only",
+			variants: testVariants[1:],
+			want: `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-STREAM-INF:BANDWIDTH=856000,AVERAGE-BANDWIDTH=800000,RESOLUTION=640x360,CODECS="avc1.4d4028"
+hls/job/360p/index.m3u8
+`,
+		},
+		{
+			name:     "renditions with audio tracks",
+			variants: testVariants,
+			audio:    testAudio,
+			want:     testMaster,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(MasterPlaylist(tt.variants, tt.audio)); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMasterPlaylistWithSubtitles(t *testing.T) {
+	want := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="audio",NAME="Deutsch",LANGUAGE="de",DEFAULT=NO,AUTOSELECT=YES,CHANNELS="6",URI="hls/job/audio_0/index.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="audio",NAME="EN",LANGUAGE="en",DEFAULT=YES,AUTOSELECT=YES,CHANNELS="2",URI="hls/job/audio_1/index.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="audio",NAME="Director's 'Cut'",LANGUAGE="en",DEFAULT=NO,AUTOSELECT=YES,CHANNELS="2",URI="hls/job/audio_2/index.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="Deutsch (erzwungen)",LANGUAGE="de",DEFAULT=NO,AUTOSELECT=YES,FORCED=YES,URI="subtitles/de-forced.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English SDH",LANGUAGE="en",DEFAULT=NO,AUTOSELECT=YES,FORCED=NO,CHARACTERISTICS="public.accessibility.transcribes-spoken-dialog,public.accessibility.describes-music-and-sound",URI="subtitles/en-sdh.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=3380000,AVERAGE-BANDWIDTH=3184000,RESOLUTION=1280x720,CODECS="avc1.4d4028,mp4a.40.2",AUDIO="audio",SUBTITLES="subs"
+hls/job/720p/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=1240000,AVERAGE-BANDWIDTH=1184000,RESOLUTION=640x360,CODECS="avc1.4d4028,mp4a.40.2",AUDIO="audio",SUBTITLES="subs"
+hls/job/360p/index.m3u8
+`
+	// As served to a user who prefers English audio
+	master := SetDefaultAudio(MasterPlaylist(testVariants, testAudio), "en")
+	if got := string(AddSubtitles(master, testSubtitles)); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSetDefaultAudio(t *testing.T) {
+	master := []byte(testMaster)
+	tests := []struct {
+		language    string
+		wantDefault string // name of the default rendition
+	}{
+		{language: "en", wantDefault: "EN"},
+		{language: "EN", wantDefault: "EN"},
+		{language: "de", wantDefault: "Deutsch"},
+		{language: "de-AT", wantDefault: "Deutsch"},
+		{language: "fr", wantDefault: "Deutsch"},
+		{language: "", wantDefault: "Deutsch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			defaults := []string{}
+			for _, line := range strings.Split(string(SetDefaultAudio(master, tt.language)), "\n") {
+				if strings.HasPrefix(line, "#EXT-X-MEDIA:TYPE=AUDIO,") && strings.Contains(line, ",DEFAULT=YES,") {
+					defaults = append(defaults, hlsAttribute(line, "NAME"))
+				}
+			}
+			if len(defaults) != 1 || defaults[0] != tt.wantDefault {
+				t.Errorf("default renditions %q, want %q", defaults, tt.wantDefault)
+			}
+		})
+	}
+}
+
+func TestSetDefaultAudioMatchesRegionalRenditions(t *testing.T) {
+	master := []byte("#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"audio\",NAME=\"EN\",LANGUAGE=\"en\",DEFAULT=YES,AUTOSELECT=YES\n" +
+		"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"audio\",NAME=\"DE\",LANGUAGE=\"de-AT\",DEFAULT=NO,AUTOSELECT=YES\n")
+	want := "#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"audio\",NAME=\"EN\",LANGUAGE=\"en\",DEFAULT=NO,AUTOSELECT=YES\n" +
+		"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"audio\",NAME=\"DE\",LANGUAGE=\"de-AT\",DEFAULT=YES,AUTOSELECT=YES\n"
+	if got := string(SetDefaultAudio(master, "de")); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,229 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ProbeResult describes the container and streams of a media file.
+type ProbeResult struct {
+	Duration    float64 // seconds
+	Width       int
+	Height      int
+	VideoCodec  string
+	HasAudio    bool
+	Audio       []AudioStream
+	Subtitles   []SubtitleStream
+	Chapters    []Chapter
+	Container   string // ffprobe format name, e.g. "matroska,webm"
+	ContentType string // MIME type to serve the file with, "" if unknown
+	Bitrate     int64  // bit/s, all streams together
+}
+
+// AudioStream is an audio stream of a media file, such as a dubbed
+// language or a commentary.
+type AudioStream struct {
+	Index         int    // among the file's audio streams, as in ffmpeg's 0:a:<index>
+	Language      string // two-letter code where known, "und" if unknown
+	Title         string
+	Channels      int
+	ChannelLayout string // e.g. "stereo" or "5.1(side)"
+	Codec         string
+	Default       bool
+}
+
+// SubtitleStream is a subtitle stream embedded in a media file.
+type SubtitleStream struct {
+	Index    int    // among the file's subtitle streams
+	Language string // two-letter code where known, "und" if unknown
+	Title    string
+	Codec    string // e.g. "subrip" or "hdmv_pgs_subtitle"
+	Forced   bool
+}
+
+// Chapter is a chapter embedded in a media file, in seconds.
+type Chapter struct {
+	Title string
+	Start float64
+	End   float64
+}
+
+// MediaProber inspects media files. FFprobe is the implementation used by
+// the server.
+type MediaProber interface {
+	Probe(ctx context.Context, input string) (ProbeResult, error)
+}
+
+// FFprobe probes media files with the ffprobe binary at Path.
+type FFprobe struct {
+	Path string
+}
+
+// Probe inspects input (a file path or URL) with ffprobe.
+func (f FFprobe) Probe(ctx context.Context, input string) (ProbeResult, error) {
+	var output, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, f.Path,
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format", "-show_streams", "-show_chapters",
+		input,
+	)
+	cmd.Stdout = &output
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return ProbeResult{}, exec.ErrNotFound
+		}
+		return ProbeResult{}, fmt.Errorf("ffprobe: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return parseProbeOutput(output.Bytes())
+}
+
+func parseProbeOutput(output []byte) (ProbeResult, error) {
+	var probe struct {
+		Streams []struct {
+			CodecType     string            `json:"codec_type"`
+			CodecName     string            `json:"codec_name"`
+			Width         int               `json:"width"`
+			Height        int               `json:"height"`
+			Channels      int               `json:"channels"`
+			ChannelLayout string            `json:"channel_layout"`
+			Tags          map[string]string `json:"tags"`
+			Disposition   map[string]int    `json:"disposition"`
+		} `json:"streams"`
+		Chapters []struct {
+			StartTime string            `json:"start_time"`
+			EndTime   string            `json:"end_time"`
+			Tags      map[string]string `json:"tags"`
+		} `json:"chapters"`
+		Format struct {
+			FormatName string            `json:"format_name"`
+			Duration   string            `json:"duration"`
+			BitRate    string            `json:"bit_rate"`
+			Tags       map[string]string `json:"tags"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return ProbeResult{}, fmt.Errorf("ffprobe: invalid output: %v", err)
+	}
+
+	result := ProbeResult{Container: probe.Format.FormatName}
+	result.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	result.Bitrate, _ = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "video":
+			if result.VideoCodec == "" {
+				result.VideoCodec = stream.CodecName
+				result.Width, result.Height = stream.Width, stream.Height
+			}
+		case "audio":
+			result.HasAudio = true
+			result.Audio = append(result.Audio, AudioStream{
+				Index:         len(result.Audio),
+				Language:      normalizeLanguage(stream.Tags["language"]),
+				Title:         stream.Tags["title"],
+				Channels:      stream.Channels,
+				ChannelLayout: stream.ChannelLayout,
+				Codec:         stream.CodecName,
+				Default:       stream.Disposition["default"] == 1,
+			})
+		case "subtitle":
+			result.Subtitles = append(result.Subtitles, SubtitleStream{
+				Index:    len(result.Subtitles),
+				Language: normalizeLanguage(stream.Tags["language"]),
+				Title:    stream.Tags["title"],
+				Codec:    stream.CodecName,
+				Forced:   stream.Disposition["forced"] == 1,
+			})
+		}
+	}
+	for i, chapter := range probe.Chapters {
+		start, _ := strconv.ParseFloat(chapter.StartTime, 64)
+		end, _ := strconv.ParseFloat(chapter.EndTime, 64)
+		title := strings.TrimSpace(chapter.Tags["title"])
+		if title == "" {
+			title = fmt.Sprintf("Chapter %d", i+1)
+		}
+		result.Chapters = append(result.Chapters, Chapter{Title: title, Start: start, End: end})
+	}
+	result.ContentType = containerContentType(result, probe.Format.Tags["major_brand"])
+	if result.VideoCodec == "" || result.Width <= 0 || result.Height <= 0 {
+		return result, errors.New("no video stream found")
+	}
+	return result, nil
+}
+
+// iso639Alpha2 maps the three-letter codes common in media files to the
+// two-letter codes used in manifests and user preferences.
+var iso639Alpha2 = map[string]string{
+	"ara": "ar", "chi": "zh", "zho": "zh", "cze": "cs", "ces": "cs", "dan": "da",
+	"dut": "nl", "nld": "nl", "eng": "en", "fin": "fi", "fre": "fr", "fra": "fr",
+	"ger": "de", "deu": "de", "gre": "el", "ell": "el", "heb": "he", "hin": "hi",
+	"hun": "hu", "ita": "it", "jpn": "ja", "kor": "ko", "nor": "no", "pol": "pl",
+	"por": "pt", "rus": "ru", "spa": "es", "swe": "sv", "tur": "tr", "ukr": "uk",
+}
+
+func normalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if alpha2, ok := iso639Alpha2[language]; ok {
+		return alpha2
+	}
+	if language == "" {
+		return "und"
+	}
+	return language
+}
+
+// containerContentType maps the container of a file to its MIME type, or
+// "" for containers it does not know.
+// ffprobe reports MP4 and QuickTime, like Matroska and WebM, as one format,
+// so the brand and codecs tell them apart.
+func containerContentType(result ProbeResult, majorBrand string) string {
+	formats := strings.Split(result.Container, ",")
+	switch formats[0] {
+	case "mov":
+		if strings.TrimSpace(majorBrand) == "qt" {
+			return "video/quicktime"
+		}
+		return "video/mp4"
+	case "matroska":
+		if oneOf(result.VideoCodec, "vp8", "vp9", "av1") && allAudioCodecs(result.Audio, "opus", "vorbis") {
+			return "video/webm"
+		}
+		return "video/x-matroska"
+	case "mpegts":
+		return "video/mp2t"
+	case "avi":
+		return "video/x-msvideo"
+	case "flv":
+		return "video/x-flv"
+	case "ogg":
+		return "video/ogg"
+	}
+	return ""
+}
+
+func oneOf(codec string, codecs ...string) bool {
+	for _, candidate := range codecs {
+		if codec == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+func allAudioCodecs(streams []AudioStream, codecs ...string) bool {
+	for _, stream := range streams {
+		if !oneOf(stream.Codec, codecs...) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package media
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func readProbeFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseProbeOutputMP4(t *testing.T) {
+	result, err := parseProbeOutput(readProbeFixture(t, "probe_mp4.json"))
+	if err != nil {
+		t.Fatalf("parseProbeOutput: %v", err)
+	}
+
+	if result.Duration != 5400.25 || result.Bitrate != 4500000 {
+		t.Errorf("duration %v, bitrate %v, want 5400.25 and 4500000", result.Duration, result.Bitrate)
+	}
+	if result.VideoCodec != "h264" || result.Width != 1920 || result.Height != 1080 {
+		t.Errorf("video %s %dx%d, want h264 1920x1080", result.VideoCodec, result.Width, result.Height)
+	}
+	if result.ContentType != "video/mp4" {
+		t.Errorf("content type %q, want video/mp4", result.ContentType)
+	}
+
+	wantAudio := []AudioStream{
+		{Index: 0, Language: "de", Title: "Deutsch", Channels: 6, ChannelLayout: "5.1", Codec: "aac", Default: true},
+		{Index: 1, Language: "en", Channels: 2, ChannelLayout: "stereo", Codec: "aac"},
+	}
+	if !result.HasAudio || !reflect.DeepEqual(result.Audio, wantAudio) {
+		t.Errorf("audio %+v, want %+v", result.Audio, wantAudio)
+	}
+
+	wantSubtitles := []SubtitleStream{{Index: 0, Language: "de", Title: "Forced", Codec: "mov_text", Forced: true}}
+	if !reflect.DeepEqual(result.Subtitles, wantSubtitles) {
+		t.Errorf("subtitles %+v, want %+v", result.Subtitles, wantSubtitles)
+	}
+
+	wantChapters := []Chapter{
+		{Title: "Intro", Start: 0, End: 95.5},
+		{Title: "Chapter 2", Start: 95.5, End: 5400.25},
+	}
+	if !reflect.DeepEqual(result.Chapters, wantChapters) {
+		t.Errorf("chapters %+v, want %+v", result.Chapters, wantChapters)
+	}
+}
+
+func TestParseProbeOutputWebM(t *testing.T) {
+	result, err := parseProbeOutput(readProbeFixture(t, "probe_webm.json"))
+	if err != nil {
+		t.Fatalf("parseProbeOutput: %v", err)
+	}
+	if result.ContentType != "video/webm" {
+		t.Errorf("content type %q, want video/webm", result.ContentType)
+	}
+	if len(result.Audio) != 1 || result.Audio[0].Language != "und" {
+		t.Errorf("audio %+v, want one stream without language", result.Audio)
+	}
+}
+
+func TestParseProbeOutputWithoutVideo(t *testing.T) {
+	result, err := parseProbeOutput(readProbeFixture(t, "probe_audio.json"))
+	if err == nil {
+		t.Fatal("expected an error for a file without video")
+	}
+	if result.Duration != 210 {
+		t.Errorf("duration %v, want 210", result.Duration)
+	}
+}
+
+func TestParseProbeOutputInvalid(t *testing.T) {
+	if _, err := parseProbeOutput([]byte("not json")); err == nil {
+		t.Fatal("expected an error for invalid output")
+	}
+}
+
+func TestContainerContentType(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     ProbeResult
+		majorBrand string
+		want       string
+	}{
+		{"mp4", ProbeResult{Container: "mov,mp4,m4a,3gp,3g2,mj2"}, "isom", "video/mp4"},
+		{"quicktime", ProbeResult{Container: "mov,mp4,m4a,3gp,3g2,mj2"}, "qt  ", "video/quicktime"},
+		{"webm", ProbeResult{Container: "matroska,webm", VideoCodec: "vp9", Audio: []AudioStream{{Codec: "opus"}}}, "", "video/webm"},
+		{"matroska", ProbeResult{Container: "matroska,webm", VideoCodec: "h264", Audio: []AudioStream{{Codec: "aac"}}}, "", "video/x-matroska"},
+		{"mpegts", ProbeResult{Container: "mpegts"}, "", "video/mp2t"},
+		{"unknown", ProbeResult{Container: "wtv"}, "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := containerContentType(test.result, test.majorBrand); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrNoSubtitleCues = errors.New("file contains no subtitle cues")
+
+var (
+	// Timestamps as in SRT (00:01:02,500) and WebVTT (00:01:02.500 or 01:02.500)
+	subtitleTimestamp = regexp.MustCompile(`^(?:(\d+):)?(\d{1,2}):(\d{1,2})[,.](\d{1,3})$`)
+	// SRT files from ASS conversions carry override tags such as {\an8}
+	assOverrideTag = regexp.MustCompile(`\{\\[^}]*\}`)
+	// WebVTT has no font tag; its colors would need a stylesheet
+	fontTag = regexp.MustCompile(`(?i)</?font[^>]*>`)
+)
+
+// ConvertToWebVTT converts SRT or WebVTT subtitles to WebVTT and shifts all
+// cues by offset. Cues that end before the start of the video are dropped,
+// cues that start before it are cut. Files that are not UTF-8 are read as
+// Latin-1, the usual encoding of older SRT files.
+func ConvertToWebVTT(input []byte, offset time.Duration) ([]byte, error) {
+	text := decodeSubtitleText(input)
+	text = strings.TrimPrefix(text, "\ufeff")
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+	// Lines with only whitespace still separate cues
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t")
+	}
+	text = strings.Join(lines, "\n")
+
+	isVTT := strings.HasPrefix(text, "WEBVTT")
+	var out strings.Builder
+	out.WriteString("WEBVTT\n\n")
+
+	cues := 0
+	for i, block := range strings.Split(text, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		if len(lines) == 0 || lines[0] == "" {
+			continue
+		}
+		if isVTT && i == 0 {
+			continue // the WEBVTT header
+		}
+		if isVTT && (strings.HasPrefix(lines[0], "STYLE") || strings.HasPrefix(lines[0], "REGION")) {
+			if cues == 0 {
+				out.WriteString(strings.Join(lines, "\n") + "\n\n")
+			}
+			continue
+		}
+		if isVTT && strings.HasPrefix(lines[0], "NOTE") {
+			continue
+		}
+
+		timing := 0
+		for timing < len(lines) && !strings.Contains(lines[timing], "-->") {
+			timing++
+		}
+		if timing == len(lines) {
+			continue
+		}
+
+		start, end, settings, err := parseCueTiming(lines[timing])
+		if err != nil {
+			return nil, err
+		}
+		start += offset
+		end += offset
+		if end <= 0 {
+			continue
+		}
+		if start < 0 {
+			start = 0
+		}
+
+		// SRT numbers its cues; WebVTT identifiers are kept
+		if isVTT && timing > 0 {
+			out.WriteString(lines[timing-1] + "\n")
+		}
+		out.WriteString(formatVTTTimestamp(start) + " --> " + formatVTTTimestamp(end))
+		if isVTT && settings != "" {
+			out.WriteString(" " + settings)
+		}
+		out.WriteString("\n")
+		for _, line := range lines[timing+1:] {
+			if !isVTT {
+				line = fontTag.ReplaceAllString(assOverrideTag.ReplaceAllString(line, ""), "")
+			}
+			out.WriteString(strings.ReplaceAll(line, "-->", "->") + "\n")
+		}
+		out.WriteString("\n")
+		cues++
+	}
+
+	if cues == 0 {
+		return nil, ErrNoSubtitleCues
+	}
+	return []byte(out.String()), nil
+}
+
+func decodeSubtitleText(input []byte) string {
+	if utf8.Valid(input) {
+		return string(input)
+	}
+	runes := make([]rune, len(input))
+	for i, b := range input {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// parseCueTiming parses "start --> end" and returns the WebVTT cue settings
+// that may follow.
+func parseCueTiming(line string) (time.Duration, time.Duration, string, error) {
+	from, rest, _ := strings.Cut(line, "-->")
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return 0, 0, "", fmt.Errorf("invalid cue timing %q", line)
+	}
+	start, err := parseSubtitleTimestamp(strings.TrimSpace(from))
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("invalid cue timing %q", line)
+	}
+	end, err := parseSubtitleTimestamp(fields[0])
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("invalid cue timing %q", line)
+	}
+	return start, end, strings.Join(fields[1:], " "), nil
+}
+
+func parseSubtitleTimestamp(value string) (time.Duration, error) {
+	match := subtitleTimestamp.FindStringSubmatch(value)
+	if match == nil {
+		return 0, errors.New("invalid timestamp")
+	}
+	hours, _ := strconv.Atoi(match[1])
+	minutes, _ := strconv.Atoi(match[2])
+	seconds, _ := strconv.Atoi(match[3])
+	// "5" means 500 ms, as the digits are a fraction
+	millis, _ := strconv.Atoi((match[4] + "00")[:3])
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute +
+		time.Duration(seconds)*time.Second + time.Duration(millis)*time.Millisecond, nil
+}
+
+func formatVTTTimestamp(d time.Duration) string {
+	millis := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", millis/3600000, millis/60000%60, millis/1000%60, millis%1000)
+}
+
+// HLSSubtitle is a subtitle rendition of a master playlist.
+type HLSSubtitle struct {
+	Name     string
+	Language string
+	Forced   bool
+	SDH      bool
+	URI      string
+}
+
+// SubtitleGroupID is the group the subtitle renditions of a master
+// playlist belong to.
+const SubtitleGroupID = "subs"
+
+// AddSubtitles adds subtitle renditions to a master playlist and refers
+// every variant to them.
+func AddSubtitles(master []byte, subtitles []HLSSubtitle) []byte {
+	if len(subtitles) == 0 {
+		return master
+	}
+
+	var renditions bytes.Buffer
+	for _, subtitle := range subtitles {
+		fmt.Fprintf(&renditions, "#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID=\"%s\",NAME=\"%s\",LANGUAGE=\"%s\",DEFAULT=NO,AUTOSELECT=YES,FORCED=%s",
+			SubtitleGroupID, quotedString(subtitle.Name), quotedString(subtitle.Language), yesNo(subtitle.Forced))
+		if subtitle.SDH {
+			renditions.WriteString(",CHARACTERISTICS=\"public.accessibility.transcribes-spoken-dialog,public.accessibility.describes-music-and-sound\"")
+		}
+		fmt.Fprintf(&renditions, ",URI=\"%s\"\n", subtitle.URI)
+	}
+
+	var out bytes.Buffer
+	inserted := false
+	for _, line := range strings.SplitAfter(string(master), "\n") {
+		if !inserted && strings.HasPrefix(line, "#EXT-X-STREAM-INF:") {
+			out.Write(renditions.Bytes())
+			inserted = true
+		}
+		if strings.HasPrefix(line, "#EXT-X-STREAM-INF:") {
+			line = strings.TrimRight(line, "\n") + ",SUBTITLES=\"" + SubtitleGroupID + "\"\n"
+		}
+		out.WriteString(line)
+	}
+	return out.Bytes()
+}
+
+// SubtitlePlaylist is the media playlist of a subtitle rendition: the whole
+// WebVTT file as a single segment.
+func SubtitlePlaylist(duration float64, uri string) []byte {
+	return []byte(fmt.Sprintf("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:%d\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXTINF:%.3f,\n%s\n#EXT-X-ENDLIST\n",
+		int(math.Ceil(duration)), duration, uri))
+}
+
+// quotedString strips what an HLS quoted-string may not contain.
+func quotedString(value string) string {
+	return strings.NewReplacer("\"", "'", "\n", " ", "\r", " ").Replace(value)
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "YES"
+	}
+	return "NO"
+}
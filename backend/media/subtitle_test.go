@@ -0,0 +1,64 @@
+package media
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConvertToWebVTT(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		offset time.Duration
+		want   string
+	}{
+		{
+			name:  "srt",
+			input: "\ufeff1\r\n00:00:01,000 --> 00:00:02,500\r\n{\\an8}<font color=\"#ffff00\">Hallo</font>\r\n\r\n2\r\n00:01:02,5 --> 00:01:04,000\r\nZwei\r\nZeilen\r\n",
+			want:  "WEBVTT\n\n00:00:01.000 --> 00:00:02.500\nHallo\n\n00:01:02.500 --> 00:01:04.000\nZwei\nZeilen\n\n",
+		},
+		{
+			name:   "offset drops and cuts early cues",
+			input:  "1\n00:00:01,000 --> 00:00:02,000\nWeg\n\n2\n00:00:02,500 --> 00:00:05,000\nGekürzt\n\n3\n00:00:10,000 --> 00:00:11,000\nVerschoben\n",
+			offset: -3 * time.Second,
+			want:   "WEBVTT\n\n00:00:00.000 --> 00:00:02.000\nGekürzt\n\n00:00:07.000 --> 00:00:08.000\nVerschoben\n\n",
+		},
+		{
+			name:   "vtt keeps identifiers, settings and styles",
+			input:  "WEBVTT - Titel\n\nSTYLE\n::cue { color: yellow }\n\nNOTE Kommentar\n\nintro\n01:02.000 --> 01:03.000 line:0 align:start\n<i>Text</i>\n",
+			offset: 500 * time.Millisecond,
+			want:   "WEBVTT\n\nSTYLE\n::cue { color: yellow }\n\nintro\n00:01:02.500 --> 00:01:03.500 line:0 align:start\n<i>Text</i>\n\n",
+		},
+		{
+			name:  "latin-1",
+			input: "1\n00:00:01,000 --> 00:00:02,000\nGr\xfc\xdfe\n",
+			want:  "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nGrüße\n\n",
+		},
+		{
+			name:  "arrows in text",
+			input: "1\n00:00:01,000 --> 00:00:02,000\nA --> B\n",
+			want:  "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nA -> B\n\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ConvertToWebVTT([]byte(test.input), test.offset)
+			if err != nil {
+				t.Fatalf("ConvertToWebVTT: %v", err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got\n%q\nwant\n%q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestConvertToWebVTTErrors(t *testing.T) {
+	if _, err := ConvertToWebVTT([]byte("WEBVTT\n\nNOTE nur ein Kommentar\n"), 0); !errors.Is(err, ErrNoSubtitleCues) {
+		t.Errorf("got %v, want ErrNoSubtitleCues", err)
+	}
+	if _, err := ConvertToWebVTT([]byte("1\n00:00:01,000 --> kaputt\nText\n"), 0); err == nil {
+		t.Error("expected an error for an invalid cue timing")
+	}
+}
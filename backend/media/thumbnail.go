@@ -0,0 +1,97 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Trickplay thumbnails: a frame every ThumbnailInterval seconds, scaled to
+// ThumbnailWidth and tiled into sprite sheets of ThumbnailColumns by
+// ThumbnailRows frames.
+const (
+	ThumbnailInterval = 10
+	ThumbnailWidth    = 160
+	ThumbnailColumns  = 10
+	ThumbnailRows     = 10
+)
+
+// ThumbnailSize returns the size of a thumbnail for a source of the given
+// size, keeping its aspect ratio.
+func ThumbnailSize(width, height int) (int, int) {
+	if width <= 0 || height <= 0 {
+		return ThumbnailWidth, even(ThumbnailWidth * 9 / 16.0)
+	}
+	return ThumbnailWidth, even(float64(ThumbnailWidth) * float64(height) / float64(width))
+}
+
+// SpriteName is the file name of the sprite sheet with the given index.
+func SpriteName(index int) string {
+	return fmt.Sprintf("sprite_%03d.jpg", index)
+}
+
+// GenerateSprites writes the sprite sheets of input to dir, named as by
+// SpriteName, and returns how many there are. The last sheet is filled up
+// with black tiles.
+func GenerateSprites(ctx context.Context, ffmpegPath, input, dir string, width, height int) (int, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpegPath,
+		"-hide_banner", "-loglevel", "error", "-y",
+		"-i", input,
+		"-map", "0:v:0", "-an", "-sn",
+		"-vf", fmt.Sprintf("fps=1/%d,scale=%d:%d,tile=%dx%d", ThumbnailInterval, width, height, ThumbnailColumns, ThumbnailRows),
+		"-q:v", "5",
+		"-f", "image2", "-start_number", "0",
+		filepath.Join(dir, "sprite_%03d.jpg"),
+	)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return 0, exec.ErrNotFound
+		}
+		return 0, fmt.Errorf("ffmpeg: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	sheets := 0
+	for {
+		if _, err := os.Stat(filepath.Join(dir, SpriteName(sheets))); err != nil {
+			break
+		}
+		sheets++
+	}
+	if sheets == 0 {
+		return 0, errors.New("ffmpeg wrote no sprite sheets")
+	}
+	return sheets, nil
+}
+
+// ThumbnailTrack renders the WebVTT thumbnail track of a video: a cue per
+// interval that points to its tile with a media fragment, such as
+// "sprite_000.jpg#xywh=160,0,160,90". Sprite URIs are relative to the track.
+func ThumbnailTrack(duration float64, width, height, sheets int) []byte {
+	perSheet := ThumbnailColumns * ThumbnailRows
+	count := min(int(math.Ceil(duration/ThumbnailInterval)), sheets*perSheet)
+
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for i := 0; i < count; i++ {
+		start := time.Duration(i*ThumbnailInterval) * time.Second
+		end := min(start+ThumbnailInterval*time.Second, time.Duration(duration*float64(time.Second)))
+		tile := i % perSheet
+		fmt.Fprintf(&b, "%s --> %s\n%s#xywh=%d,%d,%d,%d\n\n",
+			formatVTTTimestamp(start), formatVTTTimestamp(end), SpriteName(i/perSheet),
+			tile%ThumbnailColumns*width, tile/ThumbnailColumns*height, width, height)
+	}
+	return []byte(b.String())
+}
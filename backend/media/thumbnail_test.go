@@ -0,0 +1,74 @@
+package media
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThumbnailSize(t *testing.T) {
+	tests := []struct {
+		width, height int
+		wantHeight    int
+	}{
+		{1920, 1080, 90},
+		{1920, 800, 66},
+		{640, 480, 120},
+		{1080, 1920, 284},
+		{0, 0, 90},
+		{1920, 0, 90},
+	}
+
+	for _, tt := range tests {
+		width, height := ThumbnailSize(tt.width, tt.height)
+		if width != ThumbnailWidth || height != tt.wantHeight {
+			t.Errorf("ThumbnailSize(%d, %d) = %dx%d, want %dx%d", tt.width, tt.height, width, height, ThumbnailWidth, tt.wantHeight)
+		}
+	}
+}
+
+func TestThumbnailTrack(t *testing.T) {
+	want := `WEBVTT
+
+00:00:00.000 --> 00:00:10.000
+sprite_000.jpg#xywh=0,0,160,90
+
+00:00:10.000 --> 00:00:20.000
+sprite_000.jpg#xywh=160,0,160,90
+
+00:00:20.000 --> 00:00:25.500
+sprite_000.jpg#xywh=320,0,160,90
+
+`
+	if got := string(ThumbnailTrack(25.5, 160, 90, 1)); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestThumbnailTrackSheets(t *testing.T) {
+	// 201 intervals, but two sheets only hold 200 tiles
+	cues := strings.Split(strings.TrimSuffix(string(ThumbnailTrack(2005, 160, 66, 2)), "\n\n"), "\n\n")[1:]
+	if len(cues) != 200 {
+		t.Fatalf("%d cues, want 200", len(cues))
+	}
+
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{11, "00:01:50.000 --> 00:02:00.000\nsprite_000.jpg#xywh=160,66,160,66"},
+		{99, "00:16:30.000 --> 00:16:40.000\nsprite_000.jpg#xywh=1440,594,160,66"},
+		{100, "00:16:40.000 --> 00:16:50.000\nsprite_001.jpg#xywh=0,0,160,66"},
+		{199, "00:33:10.000 --> 00:33:20.000\nsprite_001.jpg#xywh=1440,594,160,66"},
+	}
+	for _, tt := range tests {
+		if cues[tt.index] != tt.want {
+			t.Errorf("cue %d = %q, want %q", tt.index, cues[tt.index], tt.want)
+		}
+	}
+}
+
+func TestSpriteName(t *testing.T) {
+	if name := SpriteName(7); name != "sprite_007.jpg" {
+		t.Errorf("SpriteName(7) = %q", name)
+	}
+}
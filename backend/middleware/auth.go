@@ -1,59 +1,110 @@
-package middleware
-
-import (
-	"net/http"
-	"strings"
-
-	"stream4you/backend/utils"
-
-	"github.com/gin-gonic/gin"
-)
-
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
-			return
-		}
-
-		// Extract token from "Bearer <token>"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
-			return
-		}
-
-		token := parts[1]
-		claims, err := utils.ValidateToken(token)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
-			return
-		}
-
-		// Store user info in context
-		c.Set("userId", claims.UserID)
-		c.Set("email", claims.Email)
-		c.Set("role", claims.Role)
-
-		c.Next()
-	}
-}
-
-func AdminMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists || role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-			c.Abort()
-			return
-		}
-		c.Next()
-	}
-}
-
-
-
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"stream4you/backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			c.Abort()
+			return
+		}
+
+		// Extract token from "Bearer <token>"
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+			c.Abort()
+			return
+		}
+
+		token := parts[1]
+		claims, err := utils.ValidateToken(token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			c.Abort()
+			return
+		}
+
+		// Store user info in context
+		c.Set("userId", claims.UserID)
+		c.Set("email", claims.Email)
+		c.Set("role", claims.Role)
+		c.Set("deviceId", tokenDeviceID(claims, token))
+
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware stores the user info like AuthMiddleware when a
+// valid token is sent, and lets anonymous requests through unchanged.
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if claims, err := utils.ValidateToken(parts[1]); err == nil {
+				c.Set("userId", claims.UserID)
+				c.Set("email", claims.Email)
+				c.Set("role", claims.Role)
+				c.Set("deviceId", tokenDeviceID(claims, parts[1]))
+			}
+		}
+		c.Next()
+	}
+}
+
+// tokenDeviceID is the device a token was issued to. Tokens from before
+// logins got a device ID are told apart by their hash.
+func tokenDeviceID(claims *utils.Claims, token string) string {
+	if claims.DeviceID != "" {
+		return claims.DeviceID
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+// StreamTokenMiddleware authenticates stream requests by the signed token
+// in the URL instead of a bearer token, as video elements and external
+// players cannot send headers. The token must have been issued for the
+// kind of media and the ID in the path.
+func StreamTokenMiddleware(kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := utils.ValidateStreamToken(c.Param("token"), c.ClientIP())
+		if err != nil || claims.Kind != kind || claims.ID != c.Param("id") {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired stream URL"})
+			c.Abort()
+			return
+		}
+
+		c.Set("userId", claims.UserID)
+		c.Set("role", claims.Role)
+		c.Set("playbackSessionId", claims.SessionID)
+
+		c.Next()
+	}
+}
+
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, exists := c.Get("role")
+		if !exists || role != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+
+
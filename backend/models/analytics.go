@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Types of player events
+const (
+	PlaybackEventStart         = "start"
+	PlaybackEventPause         = "pause"
+	PlaybackEventSeek          = "seek"
+	PlaybackEventBuffer        = "buffer"
+	PlaybackEventError         = "error"
+	PlaybackEventComplete      = "complete"
+	PlaybackEventBitrateSwitch = "bitrate_switch"
+)
+
+// PlaybackEvent is an event reported by a player. Events are kept in a
+// time-series collection, with the movie, user and playback session as
+// the series' metadata.
+type PlaybackEvent struct {
+	Time     time.Time         `json:"time" bson:"time"`
+	Meta     PlaybackEventMeta `json:"meta" bson:"meta"`
+	Type     string            `json:"type" bson:"type"`
+	Position float64           `json:"position" bson:"position"`                     // seconds into the movie
+	Played   float64           `json:"played" bson:"played"`                         // seconds played in the session so far
+	Duration float64           `json:"duration,omitempty" bson:"duration,omitempty"` // buffer: how long playback stalled, in seconds
+	From     *float64          `json:"from,omitempty" bson:"from,omitempty"`         // seek: the position before
+	Bitrate  int               `json:"bitrate,omitempty" bson:"bitrate,omitempty"`   // bitrate_switch: the new bitrate in bit/s
+	Error    string            `json:"error,omitempty" bson:"error,omitempty"`
+}
+
+type PlaybackEventMeta struct {
+	MovieID   primitive.ObjectID `json:"movieId" bson:"movieId"`
+	UserID    primitive.ObjectID `json:"userId" bson:"userId"`
+	SessionID primitive.ObjectID `json:"sessionId" bson:"sessionId"`
+}
+
+// PlaybackEventsRequest is a batch of events a player collected.
+type PlaybackEventsRequest struct {
+	Events []PlaybackEventInput `json:"events" binding:"required,min=1,max=100,dive"`
+}
+
+type PlaybackEventInput struct {
+	Type     string     `json:"type" binding:"required,oneof=start pause seek buffer error complete bitrate_switch"`
+	Time     *time.Time `json:"time"` // when it happened on the client, defaults to when it arrives
+	Position float64    `json:"position" binding:"min=0"`
+	Played   float64    `json:"played" binding:"min=0"`
+	Duration float64    `json:"duration" binding:"min=0"`
+	From     *float64   `json:"from"`
+	Bitrate  int        `json:"bitrate" binding:"min=0"`
+	Error    string     `json:"error" binding:"max=500"`
+}
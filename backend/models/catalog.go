@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	CatalogTypeMovie  = "movie"
+	CatalogTypeSeries = "series"
+)
+
+// CatalogItem is a movie or series in the combined catalog listing.
+type CatalogItem struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Type        string             `json:"type" bson:"type"`
+	Title       string             `json:"title" bson:"title"`
+	Description string             `json:"description" bson:"description"`
+	Genre       []string           `json:"genre" bson:"genre"`
+	Year        int                `json:"year" bson:"year"`
+	Rating      float64            `json:"rating" bson:"rating"`
+	PosterURL   string             `json:"posterUrl" bson:"posterUrl"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+}
@@ -0,0 +1,25 @@
+package models
+
+// Chapter is a named section of a movie. Times are in seconds from the
+// start; an End of 0 means the chapter runs to the end of the movie.
+type Chapter struct {
+	Title string  `json:"title" bson:"title"`
+	Start float64 `json:"start" bson:"start"`
+	End   float64 `json:"end" bson:"end"`
+}
+
+// PlaybackMarkers are the points players act on, in seconds: a "Skip
+// intro" button from IntroStart to IntroEnd and the end of the movie from
+// CreditsStart, which also counts as watched. Markers not set are nil.
+type PlaybackMarkers struct {
+	IntroStart   *float64 `json:"introStart,omitempty" bson:"introStart,omitempty"`
+	IntroEnd     *float64 `json:"introEnd,omitempty" bson:"introEnd,omitempty"`
+	CreditsStart *float64 `json:"creditsStart,omitempty" bson:"creditsStart,omitempty"`
+}
+
+// ChaptersRequest replaces a movie's chapters and markers.
+type ChaptersRequest struct {
+	Chapters []Chapter       `json:"chapters"`
+	Markers  PlaybackMarkers `json:"markers"`
+	Version  *int            `json:"version,omitempty"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	CollectionTypeCurated = "curated"
+	CollectionTypeSmart   = "smart"
+)
+
+// MovieFilter holds the catalog filter of GetMovies. Smart collections store
+// one as their rule, so both select movies the same way.
+type MovieFilter struct {
+	Search    string  `json:"search,omitempty" bson:"search,omitempty"`
+	Genre     string  `json:"genre,omitempty" bson:"genre,omitempty"`
+	Year      int     `json:"year,omitempty" bson:"year,omitempty"`
+	YearFrom  int     `json:"yearFrom,omitempty" bson:"yearFrom,omitempty"`
+	YearTo    int     `json:"yearTo,omitempty" bson:"yearTo,omitempty"`
+	MinRating float64 `json:"minRating,omitempty" bson:"minRating,omitempty"`
+}
+
+// CollectionRule selects the movies of a smart collection.
+type CollectionRule struct {
+	Filter MovieFilter `json:"filter" bson:"filter"`
+	Sort   string      `json:"sort,omitempty" bson:"sort,omitempty"`   // newest (default), rating, year or title
+	Limit  int         `json:"limit,omitempty" bson:"limit,omitempty"` // 0 means no limit
+}
+
+// Collection groups movies, either as a curated, ordered list (a franchise
+// or an editorial pick) or as a smart collection defined by a rule.
+type Collection struct {
+	ID          primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	Title       string               `json:"title" bson:"title"`
+	Description string               `json:"description" bson:"description"`
+	ArtworkURL  string               `json:"artworkUrl" bson:"artworkUrl"`
+	Type        string               `json:"type" bson:"type"`
+	MovieIDs    []primitive.ObjectID `json:"movieIds,omitempty" bson:"movieIds,omitempty"` // curated only, in display order
+	Rule        *CollectionRule      `json:"rule,omitempty" bson:"rule,omitempty"`         // smart only
+	CreatedAt   time.Time            `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time            `json:"updatedAt" bson:"updatedAt"`
+	CreatedBy   primitive.ObjectID   `json:"createdBy" bson:"createdBy"`
+}
+
+type CollectionRequest struct {
+	Title       string          `json:"title" binding:"required"`
+	Description string          `json:"description"`
+	ArtworkURL  string          `json:"artworkUrl"`
+	Type        string          `json:"type" binding:"required,oneof=curated smart"`
+	MovieIDs    []string        `json:"movieIds"`
+	Rule        *CollectionRule `json:"rule"`
+}
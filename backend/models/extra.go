@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	ExtraKindTrailer         = "trailer"
+	ExtraKindTeaser          = "teaser"
+	ExtraKindBehindTheScenes = "behind_the_scenes"
+	ExtraKindDeletedScene    = "deleted_scene"
+)
+
+// MovieExtra is a secondary video of a movie, such as a trailer. Extras
+// are streamed without a stream URL and also before the movie becomes
+// available.
+type MovieExtra struct {
+	ID          string    `json:"id" bson:"id"`
+	Kind        string    `json:"kind" bson:"kind"` // see ExtraKind*
+	Title       string    `json:"title" bson:"title"`
+	VideoURL    string    `json:"videoUrl" bson:"videoUrl"`                     // media store key, empty until uploaded
+	Duration    float64   `json:"duration,omitempty" bson:"duration,omitempty"` // seconds, found by probing
+	ContentType string    `json:"contentType,omitempty" bson:"contentType,omitempty"`
+	StreamURL   string    `json:"streamUrl,omitempty" bson:"-"`
+	CreatedAt   time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// ExtraRequest adds an extra or replaces its details. Without a videoUrl,
+// the video is uploaded with the extra's ID in the upload metadata.
+type ExtraRequest struct {
+	Kind     string `json:"kind" binding:"required,oneof=trailer teaser behind_the_scenes deleted_scene"`
+	Title    string `json:"title" binding:"required,max=200"`
+	VideoURL string `json:"videoUrl"`
+	Version  *int   `json:"version,omitempty"`
+}
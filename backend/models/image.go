@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	ImageKindPoster    = "poster"
+	ImageKindBackdrop  = "backdrop"
+	ImageKindThumbnail = "thumbnail"
+)
+
+// ImageVariant is one resized rendition of an uploaded image. Key is the
+// content-hash name it is stored under, URL where it is served.
+type ImageVariant struct {
+	Width  int    `json:"width" bson:"width"`
+	Height int    `json:"height" bson:"height"`
+	Format string `json:"format" bson:"format"` // jpeg or webp
+	Size   int64  `json:"size" bson:"size"`
+	Key    string `json:"-" bson:"key"`
+	URL    string `json:"url" bson:"url"`
+}
+
+type MovieImage struct {
+	Kind       string         `json:"kind" bson:"kind"`
+	Width      int            `json:"width" bson:"width"` // of the original upload
+	Height     int            `json:"height" bson:"height"`
+	Variants   []ImageVariant `json:"variants" bson:"variants"`
+	UploadedAt time.Time      `json:"uploadedAt" bson:"uploadedAt"`
+}
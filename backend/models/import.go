@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// ImportRowResult is the validation/processing outcome of a single input row.
+type ImportRowResult struct {
+	Row        int      `json:"row" bson:"row"` // 1-based, header excluded
+	ExternalID string   `json:"externalId,omitempty" bson:"externalId,omitempty"`
+	Title      string   `json:"title,omitempty" bson:"title,omitempty"`
+	Action     string   `json:"action" bson:"action"` // "create", "update" or "skip"
+	MovieID    string   `json:"movieId,omitempty" bson:"movieId,omitempty"`
+	Errors     []string `json:"errors,omitempty" bson:"errors,omitempty"`
+}
+
+type ImportSummary struct {
+	Total   int `json:"total" bson:"total"`
+	Created int `json:"created" bson:"created"`
+	Updated int `json:"updated" bson:"updated"`
+	Failed  int `json:"failed" bson:"failed"`
+}
+
+type ImportJob struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Status     string             `json:"status" bson:"status"`
+	Format     string             `json:"format" bson:"format"`
+	FileName   string             `json:"fileName" bson:"fileName"`
+	DryRun     bool               `json:"dryRun" bson:"dryRun"`
+	Mapping    map[string]string  `json:"mapping,omitempty" bson:"mapping,omitempty"`
+	Summary    ImportSummary      `json:"summary" bson:"summary"`
+	Rows       []ImportRowResult  `json:"rows" bson:"rows"` // failed rows only for background jobs
+	Error      string             `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedBy  primitive.ObjectID `json:"createdBy" bson:"createdBy"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+	FinishedAt *time.Time         `json:"finishedAt,omitempty" bson:"finishedAt,omitempty"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// MediaInfo is what probing a movie's video file found. Source is the
+// videoUrl that was probed; a new video is probed again.
+type MediaInfo struct {
+	Source      string                `json:"source" bson:"source"`
+	Container   string                `json:"container,omitempty" bson:"container,omitempty"` // ffprobe format name
+	ContentType string                `json:"contentType,omitempty" bson:"contentType,omitempty"`
+	Duration    float64               `json:"duration" bson:"duration"` // seconds
+	Bitrate     int64                 `json:"bitrate" bson:"bitrate"`   // bit/s
+	Width       int                   `json:"width" bson:"width"`
+	Height      int                   `json:"height" bson:"height"`
+	VideoCodec  string                `json:"videoCodec,omitempty" bson:"videoCodec,omitempty"`
+	Audio       []MediaAudioStream    `json:"audio" bson:"audio"`
+	Subtitles   []MediaSubtitleStream `json:"subtitles" bson:"subtitles"`
+	Chapters    []Chapter             `json:"chapters" bson:"chapters"`               // embedded in the container
+	Error       string                `json:"error,omitempty" bson:"error,omitempty"` // set if probing failed
+	ProbedAt    time.Time             `json:"probedAt" bson:"probedAt"`
+}
+
+type MediaAudioStream struct {
+	Language      string `json:"language" bson:"language"`
+	Title         string `json:"title,omitempty" bson:"title,omitempty"`
+	Codec         string `json:"codec" bson:"codec"`
+	Channels      int    `json:"channels" bson:"channels"`
+	ChannelLayout string `json:"channelLayout,omitempty" bson:"channelLayout,omitempty"`
+	Default       bool   `json:"default" bson:"default"`
+}
+
+type MediaSubtitleStream struct {
+	Language string `json:"language" bson:"language"`
+	Title    string `json:"title,omitempty" bson:"title,omitempty"`
+	Codec    string `json:"codec" bson:"codec"`
+	Forced   bool   `json:"forced" bson:"forced"`
+}
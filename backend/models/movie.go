@@ -1,61 +1,120 @@
-package models
-
-import (
-	"time"
-
-	"go.mongodb.org/mongo-driver/bson/primitive"
-)
-
-type Movie struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Title       string             `json:"title" bson:"title" binding:"required"`
-	Description string             `json:"description" bson:"description"`
-	Genre       []string           `json:"genre" bson:"genre"`
-	Year        int                `json:"year" bson:"year" binding:"required"`
-	Duration    int                `json:"duration" bson:"duration"` // in minutes
-	Rating      float64            `json:"rating" bson:"rating"`     // average rating
-	PosterURL   string             `json:"posterUrl" bson:"posterUrl"`
-	VideoURL    string             `json:"videoUrl" bson:"videoUrl"` // path to video file
-	Director    string             `json:"director" bson:"director"`
-	Cast        []string           `json:"cast" bson:"cast"`
-	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
-	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
-	CreatedBy   primitive.ObjectID `json:"createdBy" bson:"createdBy"`
-}
-
-type Review struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	MovieID   primitive.ObjectID `json:"movieId" bson:"movieId" binding:"required"`
-	UserID    primitive.ObjectID `json:"userId" bson:"userId" binding:"required"`
-	Rating    int                `json:"rating" bson:"rating" binding:"required,min=1,max=5"`
-	Comment   string             `json:"comment" bson:"comment"`
-	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
-	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
-}
-
-type CreateMovieRequest struct {
-	Title       string   `json:"title" binding:"required"`
-	Description string   `json:"description"`
-	Genre       []string `json:"genre"`
-	Year        int      `json:"year" binding:"required"`
-	Duration    int      `json:"duration"`
-	PosterURL   string   `json:"posterUrl"`
-	VideoURL    string   `json:"videoUrl"`
-	Director    string   `json:"director"`
-	Cast        []string `json:"cast"`
-}
-
-type UpdateMovieRequest struct {
-	Title       string   `json:"title"`
-	Description string   `json:"description"`
-	Genre       []string `json:"genre"`
-	Year        int      `json:"year"`
-	Duration    int      `json:"duration"`
-	PosterURL   string   `json:"posterUrl"`
-	VideoURL    string   `json:"videoUrl"`
-	Director    string   `json:"director"`
-	Cast        []string `json:"cast"`
-}
-
-
-
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Movie struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ExternalID     string             `json:"externalId,omitempty" bson:"externalId,omitempty"` // ID in the source catalog, used for import upserts
+	Title          string             `json:"title" bson:"title" binding:"required"`
+	Description    string             `json:"description" bson:"description"`
+	Genre          []string           `json:"genre" bson:"genre"`
+	Year           int                `json:"year" bson:"year" binding:"required"`
+	Duration       int                `json:"duration" bson:"duration"` // in minutes
+	Rating         float64            `json:"rating" bson:"rating"`     // average rating
+	PosterURL      string             `json:"posterUrl" bson:"posterUrl"`
+	VideoURL       string             `json:"videoUrl" bson:"videoUrl"` // path to video file
+	MediaInfo      *MediaInfo         `json:"mediaInfo,omitempty" bson:"mediaInfo,omitempty"`
+	Director       string             `json:"director" bson:"director"`
+	Cast           []string           `json:"cast" bson:"cast"`
+	Credits        []MovieCredit      `json:"credits,omitempty" bson:"credits,omitempty"`           // director and cast above are derived from these
+	Translations   []MovieTranslation `json:"translations,omitempty" bson:"translations,omitempty"` // title and description above are in the default language
+	Images         []MovieImage       `json:"images,omitempty" bson:"images,omitempty"`             // uploaded artwork, one per kind; posterUrl points to the poster
+	Subtitles      []SubtitleTrack    `json:"subtitles,omitempty" bson:"subtitles,omitempty"`
+	Chapters       []Chapter          `json:"chapters,omitempty" bson:"chapters,omitempty"`
+	Markers        *PlaybackMarkers   `json:"markers,omitempty" bson:"markers,omitempty"`
+	Extras         []MovieExtra       `json:"extras,omitempty" bson:"extras,omitempty"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time          `json:"updatedAt" bson:"updatedAt"`
+	CreatedBy      primitive.ObjectID `json:"createdBy" bson:"createdBy"`
+	Version        int                `json:"version" bson:"version"`         // incremented on every metadata change, used as ETag
+	Status         string             `json:"status" bson:"status,omitempty"` // see MovieStatus*; movies without status are published
+	AvailableFrom  *time.Time         `json:"availableFrom,omitempty" bson:"availableFrom,omitempty"`
+	AvailableUntil *time.Time         `json:"availableUntil,omitempty" bson:"availableUntil,omitempty"`
+	Package        *StreamPackage     `json:"package,omitempty" bson:"package,omitempty"`
+	StreamStatus   string             `json:"streamStatus,omitempty" bson:"streamStatus,omitempty"`
+
+	// Set while the movie is in the trash
+	DeletedAt *time.Time          `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy *primitive.ObjectID `json:"deletedBy,omitempty" bson:"deletedBy,omitempty"`
+}
+
+const (
+	MovieStatusDraft     = "draft"     // only visible to admins
+	MovieStatusScheduled = "scheduled" // published, availableFrom is still ahead
+	MovieStatusPublished = "published"
+	MovieStatusExpired   = "expired" // published, availableUntil has passed
+)
+
+type MovieTranslation struct {
+	Language    string `json:"language" bson:"language"`
+	Title       string `json:"title" bson:"title"`
+	Description string `json:"description" bson:"description"`
+}
+
+// GenreTranslation holds the display names of a genre. Genre is the name
+// stored on movies, which filters keep using.
+type GenreTranslation struct {
+	Genre string            `json:"genre" bson:"_id"`
+	Names map[string]string `json:"names" bson:"names"` // by language
+}
+
+type Review struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	MovieID   primitive.ObjectID `json:"movieId" bson:"movieId,omitempty"`
+	SeriesID  primitive.ObjectID `json:"seriesId,omitempty" bson:"seriesId,omitempty"`   // set for series reviews
+	EpisodeID primitive.ObjectID `json:"episodeId,omitempty" bson:"episodeId,omitempty"` // optional, review of a single episode
+	UserID    primitive.ObjectID `json:"userId" bson:"userId" binding:"required"`
+	Rating    int                `json:"rating" bson:"rating" binding:"required,min=1,max=5"`
+	Comment   string             `json:"comment" bson:"comment"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+type CreateMovieRequest struct {
+	ExternalID     string     `json:"externalId"`
+	Title          string     `json:"title" binding:"required"`
+	Description    string     `json:"description"`
+	Genre          []string   `json:"genre"`
+	Year           int        `json:"year" binding:"required"`
+	Duration       int        `json:"duration"`
+	PosterURL      string     `json:"posterUrl"`
+	VideoURL       string     `json:"videoUrl"`
+	Director       string     `json:"director"`
+	Cast           []string   `json:"cast"`
+	Status         string     `json:"status" binding:"omitempty,oneof=draft published"` // defaults to published
+	AvailableFrom  *time.Time `json:"availableFrom"`
+	AvailableUntil *time.Time `json:"availableUntil"`
+}
+
+// UpdateMovieRequest replaces all editable fields of a movie (PUT). Fields
+// left out are cleared; use PATCH for partial updates.
+type UpdateMovieRequest struct {
+	ExternalID  string   `json:"externalId"`
+	Title       string   `json:"title" binding:"required"`
+	Description string   `json:"description"`
+	Genre       []string `json:"genre"`
+	Year        int      `json:"year" binding:"required"`
+	Duration    int      `json:"duration"`
+	PosterURL   string   `json:"posterUrl"`
+	VideoURL    string   `json:"videoUrl"`
+	Director    string   `json:"director"`
+	Cast        []string `json:"cast"`
+	Version     *int     `json:"version,omitempty"` // optional, alternative to the If-Match header
+}
+
+// PublicationRequest sets the editorial state and availability window of a
+// movie. Status is draft or published; the stored status is derived from it
+// and the window.
+type PublicationRequest struct {
+	Status         string     `json:"status" binding:"required,oneof=draft published"`
+	AvailableFrom  *time.Time `json:"availableFrom"`
+	AvailableUntil *time.Time `json:"availableUntil"`
+	Version        *int       `json:"version,omitempty"`
+}
+
+
+
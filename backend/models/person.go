@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	CreditRoleDirector = "director"
+	CreditRoleWriter   = "writer"
+	CreditRoleActor    = "actor"
+)
+
+type Person struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name      string             `json:"name" bson:"name"`
+	Aliases   []string           `json:"aliases" bson:"aliases"` // other spellings, e.g. "C. Nolan"
+	Bio       string             `json:"bio" bson:"bio"`
+	PhotoURL  string             `json:"photoUrl" bson:"photoUrl"`
+	BirthDate *time.Time         `json:"birthDate,omitempty" bson:"birthDate,omitempty"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// MovieCredit links a person to a movie. Name is a copy of the person's
+// name at the time the credit was saved, so listings need no lookup.
+type MovieCredit struct {
+	PersonID  primitive.ObjectID `json:"personId" bson:"personId"`
+	Name      string             `json:"name" bson:"name"`
+	Role      string             `json:"role" bson:"role"`
+	Character string             `json:"character,omitempty" bson:"character,omitempty"` // actors only
+}
+
+type PersonRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Aliases   []string   `json:"aliases"`
+	Bio       string     `json:"bio"`
+	PhotoURL  string     `json:"photoUrl"`
+	BirthDate *time.Time `json:"birthDate"`
+}
+
+type MovieCreditRequest struct {
+	PersonID  string `json:"personId" binding:"required"`
+	Role      string `json:"role" binding:"required,oneof=director writer actor"`
+	Character string `json:"character"`
+}
+
+// FilmographyEntry is one movie in a person's filmography with all roles
+// the person had in it.
+type FilmographyEntry struct {
+	MovieID   primitive.ObjectID `json:"movieId"`
+	Title     string             `json:"title"`
+	Year      int                `json:"year"`
+	PosterURL string             `json:"posterUrl"`
+	Roles     []MovieCredit      `json:"roles"`
+}
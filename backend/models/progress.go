@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WatchProgress is where a user stopped in a movie. There is one document
+// per user and movie, updated by the player's heartbeats.
+type WatchProgress struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID         primitive.ObjectID `json:"userId" bson:"userId"`
+	MovieID        primitive.ObjectID `json:"movieId" bson:"movieId"`
+	Position       float64            `json:"position" bson:"position"` // seconds
+	Duration       float64            `json:"duration" bson:"duration"` // seconds, as reported by the player
+	ResumePosition float64            `json:"resumePosition" bson:"-"`  // where playback should continue, 0 to start over
+	Watched        bool               `json:"watched" bson:"watched"`   // played past the watched threshold at least once
+	WatchedAt      *time.Time         `json:"watchedAt,omitempty" bson:"watchedAt,omitempty"`
+	CreditsStart   float64            `json:"-" bson:"creditsStart,omitempty"` // the movie's credits marker, playing past it finishes the movie
+	CreatedAt      time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// ProgressRequest is a playback heartbeat.
+type ProgressRequest struct {
+	Position  float64 `json:"position" binding:"min=0"`
+	Duration  float64 `json:"duration" binding:"required,gt=0"`
+	SessionID string  `json:"sessionId"` // from the stream URL response, optional
+}
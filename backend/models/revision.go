@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	RevisionActionCreate   = "create"
+	RevisionActionUpdate   = "update"
+	RevisionActionImport   = "import"
+	RevisionActionRollback = "rollback"
+	RevisionActionMigrate  = "migrate"
+	RevisionActionDelete   = "delete"  // moved to the trash
+	RevisionActionRestore  = "restore" // restored from the trash
+)
+
+type FieldChange struct {
+	Field string      `json:"field" bson:"field"`
+	Old   interface{} `json:"old" bson:"old"`
+	New   interface{} `json:"new" bson:"new"`
+}
+
+// MovieRevision records one change to a movie's metadata. Version is the
+// movie version the change produced.
+type MovieRevision struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	MovieID      primitive.ObjectID `json:"movieId" bson:"movieId"`
+	Version      int                `json:"version" bson:"version"`
+	Action       string             `json:"action" bson:"action"`
+	Changes      []FieldChange      `json:"changes" bson:"changes"`
+	RolledBackTo *int               `json:"rolledBackTo,omitempty" bson:"rolledBackTo,omitempty"`
+	ChangedBy    primitive.ObjectID `json:"changedBy" bson:"changedBy"`
+	ChangedAt    time.Time          `json:"changedAt" bson:"changedAt"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Series struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Title       string             `json:"title" bson:"title"`
+	Description string             `json:"description" bson:"description"`
+	Genre       []string           `json:"genre" bson:"genre"`
+	Year        int                `json:"year" bson:"year"`                           // first aired
+	EndYear     int                `json:"endYear,omitempty" bson:"endYear,omitempty"` // 0 while still running
+	PosterURL   string             `json:"posterUrl" bson:"posterUrl"`
+	Creator     string             `json:"creator" bson:"creator"`
+	Cast        []string           `json:"cast" bson:"cast"`
+	Rating      float64            `json:"rating" bson:"rating"` // average over series and episode reviews
+	RatingCount int                `json:"ratingCount" bson:"ratingCount"`
+	Seasons     []Season           `json:"seasons" bson:"seasons"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+	CreatedBy   primitive.ObjectID `json:"createdBy" bson:"createdBy"`
+}
+
+type Season struct {
+	Number    int    `json:"number" bson:"number"`
+	Title     string `json:"title" bson:"title"`
+	Year      int    `json:"year" bson:"year"`
+	PosterURL string `json:"posterUrl" bson:"posterUrl"`
+}
+
+type Episode struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	SeriesID      primitive.ObjectID `json:"seriesId" bson:"seriesId"`
+	SeasonNumber  int                `json:"seasonNumber" bson:"seasonNumber"`
+	EpisodeNumber int                `json:"episodeNumber" bson:"episodeNumber"`
+	Title         string             `json:"title" bson:"title"`
+	Description   string             `json:"description" bson:"description"`
+	Duration      int                `json:"duration" bson:"duration"` // in minutes
+	VideoURL      string             `json:"videoUrl" bson:"videoUrl"`
+	AirDate       *time.Time         `json:"airDate,omitempty" bson:"airDate,omitempty"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+type SeriesRequest struct {
+	Title       string   `json:"title" binding:"required"`
+	Description string   `json:"description"`
+	Genre       []string `json:"genre"`
+	Year        int      `json:"year" binding:"required"`
+	EndYear     int      `json:"endYear"`
+	PosterURL   string   `json:"posterUrl"`
+	Creator     string   `json:"creator"`
+	Cast        []string `json:"cast"`
+}
+
+type SeasonRequest struct {
+	Title     string `json:"title"`
+	Year      int    `json:"year"`
+	PosterURL string `json:"posterUrl"`
+}
+
+type EpisodeRequest struct {
+	SeasonNumber  int        `json:"seasonNumber" binding:"required,min=1"`
+	EpisodeNumber int        `json:"episodeNumber" binding:"required,min=1"`
+	Title         string     `json:"title" binding:"required"`
+	Description   string     `json:"description"`
+	Duration      int        `json:"duration" binding:"min=0"`
+	VideoURL      string     `json:"videoUrl"`
+	AirDate       *time.Time `json:"airDate"`
+}
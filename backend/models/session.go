@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PlaybackSession is one device playing a movie or episode. Sessions are
+// started when a stream URL is requested and kept alive by stream requests
+// and progress heartbeats. A session counts towards the user's stream limit
+// until it ends or misses heartbeats for the session timeout.
+type PlaybackSession struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `json:"userId" bson:"userId"`
+	DeviceID   string             `json:"deviceId" bson:"deviceId"`
+	DeviceName string             `json:"deviceName" bson:"deviceName"`
+	IP         string             `json:"ip" bson:"ip"`
+	Kind       string             `json:"kind" bson:"kind"` // movie or episode
+	MediaID    primitive.ObjectID `json:"mediaId" bson:"mediaId"`
+	StartedAt  time.Time          `json:"startedAt" bson:"startedAt"`
+	LastSeenAt time.Time          `json:"lastSeenAt" bson:"lastSeenAt"`
+	EndedAt    *time.Time         `json:"endedAt,omitempty" bson:"endedAt,omitempty"` // set when the session was stopped
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SubtitleTrack is a subtitle file of a movie, stored as WebVTT.
+type SubtitleTrack struct {
+	ID         string    `json:"id" bson:"id"`
+	Language   string    `json:"language" bson:"language"` // BCP 47 tag such as "de" or "en-US"
+	Label      string    `json:"label" bson:"label"`
+	Forced     bool      `json:"forced" bson:"forced"` // only the foreign-language parts
+	SDH        bool      `json:"sdh" bson:"sdh"`       // for the deaf and hard of hearing
+	Format     string    `json:"format" bson:"format"` // format of the upload, srt or vtt
+	Offset     int64     `json:"offset" bson:"offset"` // milliseconds the cues were shifted by
+	Key        string    `json:"-" bson:"key"`
+	UploadedAt time.Time `json:"uploadedAt" bson:"uploadedAt"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	TranscodeStatusQueued     = "queued"
+	TranscodeStatusProcessing = "processing"
+	TranscodeStatusReady      = "ready"
+	TranscodeStatusFailed     = "failed"
+)
+
+// TranscodeJob packages a movie's video for adaptive streaming. Jobs are
+// picked up by the transcoding workers in the order they were queued.
+type TranscodeJob struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	MovieID    primitive.ObjectID `json:"movieId" bson:"movieId"`
+	Source     string             `json:"source" bson:"source"`     // media store key of the video
+	Status     string             `json:"status" bson:"status"`     // see TranscodeStatus*
+	Progress   int                `json:"progress" bson:"progress"` // renditions done, audio included
+	Renditions int                `json:"renditions" bson:"renditions"`
+	Error      string             `json:"error,omitempty" bson:"error,omitempty"`
+	Files      []string           `json:"-" bson:"files,omitempty"` // stored keys, removed with the package
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+	CreatedBy  primitive.ObjectID `json:"createdBy,omitempty" bson:"createdBy,omitempty"`
+	StartedAt  *time.Time         `json:"startedAt,omitempty" bson:"startedAt,omitempty"`
+	FinishedAt *time.Time         `json:"finishedAt,omitempty" bson:"finishedAt,omitempty"`
+}
+
+// StreamPackage is a movie's packaged adaptive stream. Its files are stored
+// below Prefix, with the HLS master playlist at Prefix/master.m3u8, for
+// packages with DASH, the MPD at Prefix/manifest.mpd and, with thumbnails,
+// the WebVTT thumbnail track at Prefix/thumbnails/thumbnails.vtt. The movie's
+// streamStatus holds the TranscodeStatus* of its latest job, so a package
+// stays in use while a newer one is being made.
+type StreamPackage struct {
+	JobID       primitive.ObjectID  `json:"jobId" bson:"jobId"`
+	Prefix      string              `json:"-" bson:"prefix"`
+	Duration    float64             `json:"duration" bson:"duration"` // seconds
+	HasAudio    bool                `json:"hasAudio" bson:"hasAudio"`
+	DASH        bool                `json:"dash" bson:"dash"`
+	Thumbnails  bool                `json:"thumbnails" bson:"thumbnails"`
+	Renditions  []PackageRendition  `json:"renditions" bson:"renditions"`
+	AudioTracks []PackageAudioTrack `json:"audioTracks" bson:"audioTracks,omitempty"`
+	CreatedAt   time.Time           `json:"createdAt" bson:"createdAt"`
+}
+
+type PackageRendition struct {
+	Name      string `json:"name" bson:"name"`
+	Width     int    `json:"width" bson:"width"`
+	Height    int    `json:"height" bson:"height"`
+	Bandwidth int    `json:"bandwidth" bson:"bandwidth"` // peak bit/s
+}
+
+// PackageAudioTrack is an audio rendition of a package, found by probing
+// the source.
+type PackageAudioTrack struct {
+	Name     string `json:"name" bson:"name"`
+	Language string `json:"language" bson:"language"` // "und" if the source does not tell
+	Label    string `json:"label" bson:"label"`
+	Channels int    `json:"channels" bson:"channels"`
+	Default  bool   `json:"default" bson:"default"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	UploadStatusUploading = "uploading"
+	UploadStatusCompleted = "completed"
+)
+
+// Upload is a resumable (tus) video upload. Once all bytes have arrived the
+// file is moved next to the other videos and linked to the movie or, with
+// an ExtraID, to that extra of the movie.
+type Upload struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	MovieID     primitive.ObjectID `json:"movieId" bson:"movieId"`
+	ExtraID     string             `json:"extraId,omitempty" bson:"extraId,omitempty"`
+	Filename    string             `json:"filename" bson:"filename"`
+	Length      int64              `json:"length" bson:"length"`
+	Offset      int64              `json:"offset" bson:"offset"`
+	Metadata    string             `json:"metadata" bson:"metadata"` // raw Upload-Metadata header
+	Status      string             `json:"status" bson:"status"`
+	VideoURL    string             `json:"videoUrl,omitempty" bson:"videoUrl,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+	CompletedAt *time.Time         `json:"completedAt,omitempty" bson:"completedAt,omitempty"`
+	CreatedBy   primitive.ObjectID `json:"createdBy" bson:"createdBy"`
+}
@@ -1,33 +1,45 @@
-package models
-
-import (
-	"time"
-
-	"go.mongodb.org/mongo-driver/bson/primitive"
-)
-
-type User struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Email     string             `json:"email" bson:"email" binding:"required,email"`
-	Password  string             `json:"password" bson:"password" binding:"required,min=6"`
-	FirstName string             `json:"firstName" bson:"firstName" binding:"required"`
-	LastName  string             `json:"lastName" bson:"lastName" binding:"required"`
-	Role      string             `json:"role" bson:"role"` // "user" or "admin"
-	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
-	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
-}
-
-type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
-}
-
-type RegisterRequest struct {
-	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=6"`
-	FirstName string `json:"firstName" binding:"required"`
-	LastName  string `json:"lastName" binding:"required"`
-}
-
-
-
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type User struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Email       string             `json:"email" bson:"email" binding:"required,email"`
+	Password    string             `json:"password" bson:"password" binding:"required,min=6"`
+	FirstName   string             `json:"firstName" bson:"firstName" binding:"required"`
+	LastName    string             `json:"lastName" bson:"lastName" binding:"required"`
+	Role        string             `json:"role" bson:"role"` // "user" or "admin"
+	Plan        string             `json:"plan,omitempty" bson:"plan,omitempty"`
+	Preferences UserPreferences    `json:"preferences" bson:"preferences,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// UserPreferences are the playback settings of a user.
+type UserPreferences struct {
+	AudioLanguage string `json:"audioLanguage,omitempty" bson:"audioLanguage,omitempty"` // language tag such as de or en-US
+}
+
+// PlanRequest assigns a user to a plan; an empty plan removes it.
+type PlanRequest struct {
+	Plan string `json:"plan"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RegisterRequest struct {
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password" binding:"required,min=6"`
+	FirstName string `json:"firstName" binding:"required"`
+	LastName  string `json:"lastName" binding:"required"`
+}
+
+
+
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"stream4you/backend/controllers"
+	"stream4you/backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAdminRoutes(router *gin.RouterGroup) {
+	// tus clients discover the server's capabilities without credentials
+	router.OPTIONS("/admin/uploads", controllers.TusOptions)
+	router.OPTIONS("/admin/uploads/:id", controllers.TusOptions)
+
+	admin := router.Group("/admin", middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		admin.POST("/movies/import", controllers.ImportMovies)
+		admin.GET("/movies/import/:jobId", controllers.GetImportJob)
+		admin.GET("/movies/export", controllers.ExportMovies)
+
+		admin.GET("/movies/trash", controllers.GetTrash)
+		admin.POST("/movies/:id/restore", controllers.RestoreMovie)
+		admin.DELETE("/movies/:id/purge", controllers.PurgeMovie)
+		admin.POST("/movies/:id/transcode", controllers.TranscodeMovie)
+		admin.GET("/movies/:id/transcode", controllers.GetTranscodeJobs)
+		admin.POST("/movies/:id/probe", controllers.ProbeMovie)
+
+		admin.POST("/people/migrate", controllers.MigratePeople)
+
+		admin.GET("/genres/translations", controllers.GetGenreTranslations)
+		admin.PUT("/genres/:genre/translations", controllers.SetGenreTranslation)
+
+		admin.GET("/media/invalid-paths", controllers.GetInvalidMediaPaths)
+
+		admin.GET("/analytics/plays", controllers.GetDailyPlays)
+		admin.GET("/analytics/movies", controllers.GetMovieAnalytics)
+
+		admin.PUT("/users/:id/plan", controllers.SetUserPlan)
+
+		admin.POST("/uploads", controllers.CreateUpload)
+		admin.HEAD("/uploads/:id", controllers.HeadUpload)
+		admin.PATCH("/uploads/:id", controllers.PatchUpload)
+		admin.DELETE("/uploads/:id", controllers.DeleteUpload)
+	}
+}
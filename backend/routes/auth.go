@@ -1,20 +1,21 @@
-package routes
-
-import (
-	"stream4you/backend/controllers"
-	"stream4you/backend/middleware"
-
-	"github.com/gin-gonic/gin"
-)
-
-func SetupAuthRoutes(router *gin.RouterGroup) {
-	auth := router.Group("/auth")
-	{
-		auth.POST("/register", controllers.Register)
-		auth.POST("/login", controllers.Login)
-		auth.GET("/profile", middleware.AuthMiddleware(), controllers.GetProfile)
-	}
-}
-
-
-
+package routes
+
+import (
+	"stream4you/backend/controllers"
+	"stream4you/backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAuthRoutes(router *gin.RouterGroup) {
+	auth := router.Group("/auth")
+	{
+		auth.POST("/register", controllers.Register)
+		auth.POST("/login", controllers.Login)
+		auth.GET("/profile", middleware.AuthMiddleware(), controllers.GetProfile)
+		auth.PUT("/preferences", middleware.AuthMiddleware(), controllers.UpdatePreferences)
+	}
+}
+
+
+
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"stream4you/backend/controllers"
+	"stream4you/backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupCollectionRoutes(router *gin.RouterGroup) {
+	collections := router.Group("/collections")
+	{
+		// Public routes
+		collections.GET("", controllers.GetCollections)
+		collections.GET("/:id", controllers.GetCollection)
+
+		// Admin routes
+		admin := collections.Group("", middleware.AuthMiddleware(), middleware.AdminMiddleware())
+		{
+			admin.POST("", controllers.CreateCollection)
+			admin.PUT("/:id", controllers.UpdateCollection)
+			admin.DELETE("/:id", controllers.DeleteCollection)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"stream4you/backend/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupImageRoutes(router *gin.RouterGroup) {
+	router.GET("/images/:file", controllers.ServeImage)
+}
@@ -1,32 +1,50 @@
-package routes
-
-import (
-	"stream4you/backend/controllers"
-	"stream4you/backend/middleware"
-
-	"github.com/gin-gonic/gin"
-)
-
-func SetupMovieRoutes(router *gin.RouterGroup) {
-	movies := router.Group("/movies")
-	{
-		// Public routes
-		movies.GET("", controllers.GetMovies)
-		movies.GET("/genres", controllers.GetGenres)
-		movies.GET("/:id", controllers.GetMovie)
-
-		// Protected routes
-		movies.POST("/:id/reviews", middleware.AuthMiddleware(), controllers.AddReview)
-
-		// Admin routes
-		admin := movies.Group("", middleware.AuthMiddleware(), middleware.AdminMiddleware())
-		{
-			admin.POST("", controllers.CreateMovie)
-			admin.PUT("/:id", controllers.UpdateMovie)
-			admin.DELETE("/:id", controllers.DeleteMovie)
-		}
-	}
-}
-
-
-
+package routes
+
+import (
+	"stream4you/backend/controllers"
+	"stream4you/backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupMovieRoutes(router *gin.RouterGroup) {
+	movies := router.Group("/movies")
+	{
+		// Public routes
+		movies.GET("", middleware.OptionalAuthMiddleware(), controllers.GetMovies)
+		movies.GET("/genres", controllers.GetGenres)
+		movies.GET("/continue-watching", middleware.AuthMiddleware(), controllers.GetContinueWatching)
+		movies.GET("/:id", middleware.OptionalAuthMiddleware(), controllers.GetMovie)
+
+		// Protected routes
+		movies.POST("/:id/reviews", middleware.AuthMiddleware(), controllers.AddReview)
+		movies.PUT("/:id/progress", middleware.AuthMiddleware(), controllers.UpdateWatchProgress)
+
+		// Admin routes
+		admin := movies.Group("", middleware.AuthMiddleware(), middleware.AdminMiddleware())
+		{
+			admin.POST("", controllers.CreateMovie)
+			admin.PUT("/:id", controllers.UpdateMovie)
+			admin.PATCH("/:id", controllers.PatchMovie)
+			admin.DELETE("/:id", controllers.DeleteMovie)
+			admin.PUT("/:id/publication", controllers.SetMoviePublication)
+			admin.PUT("/:id/credits", controllers.SetMovieCredits)
+			admin.POST("/:id/images/:kind", controllers.UploadMovieImage)
+			admin.DELETE("/:id/images/:kind", controllers.DeleteMovieImage)
+			admin.POST("/:id/subtitles", controllers.UploadMovieSubtitle)
+			admin.DELETE("/:id/subtitles/:trackId", controllers.DeleteMovieSubtitle)
+			admin.PUT("/:id/chapters", controllers.SetMovieChapters)
+			admin.POST("/:id/chapters/import", controllers.ImportMovieChapters)
+			admin.POST("/:id/extras", controllers.AddMovieExtra)
+			admin.PUT("/:id/extras/:extraId", controllers.UpdateMovieExtra)
+			admin.DELETE("/:id/extras/:extraId", controllers.DeleteMovieExtra)
+			admin.PUT("/:id/translations/:lang", controllers.SetMovieTranslation)
+			admin.DELETE("/:id/translations/:lang", controllers.DeleteMovieTranslation)
+			admin.GET("/:id/revisions", controllers.GetMovieRevisions)
+			admin.POST("/:id/revisions/:version/rollback", controllers.RollbackMovie)
+		}
+	}
+}
+
+
+
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"stream4you/backend/controllers"
+	"stream4you/backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupPeopleRoutes(router *gin.RouterGroup) {
+	people := router.Group("/people")
+	{
+		// Public routes
+		people.GET("", controllers.GetPeople)
+		people.GET("/:id", controllers.GetPerson)
+
+		// Admin routes
+		admin := people.Group("", middleware.AuthMiddleware(), middleware.AdminMiddleware())
+		{
+			admin.POST("", controllers.CreatePerson)
+			admin.PUT("/:id", controllers.UpdatePerson)
+			admin.DELETE("/:id", controllers.DeletePerson)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"stream4you/backend/controllers"
+	"stream4you/backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupSeriesRoutes(router *gin.RouterGroup) {
+	router.GET("/catalog", controllers.GetCatalog)
+
+	series := router.Group("/series")
+	{
+		// Public routes
+		series.GET("", controllers.GetSeriesList)
+		series.GET("/:id", controllers.GetSeries)
+		series.GET("/:id/seasons/:season/episodes", controllers.GetSeasonEpisodes)
+
+		// Protected routes
+		series.POST("/:id/reviews", middleware.AuthMiddleware(), controllers.AddSeriesReview)
+
+		// Admin routes
+		admin := series.Group("", middleware.AuthMiddleware(), middleware.AdminMiddleware())
+		{
+			admin.POST("", controllers.CreateSeries)
+			admin.PUT("/:id", controllers.UpdateSeries)
+			admin.DELETE("/:id", controllers.DeleteSeries)
+			admin.PUT("/:id/seasons/:season", controllers.UpsertSeason)
+			admin.DELETE("/:id/seasons/:season", controllers.DeleteSeason)
+			admin.POST("/:id/episodes", controllers.CreateEpisode)
+		}
+	}
+
+	episodes := router.Group("/episodes")
+	{
+		episodes.GET("/:id", controllers.GetEpisode)
+		episodes.GET("/:id/next", controllers.GetNextEpisode)
+
+		admin := episodes.Group("", middleware.AuthMiddleware(), middleware.AdminMiddleware())
+		{
+			admin.PUT("/:id", controllers.UpdateEpisode)
+			admin.DELETE("/:id", controllers.DeleteEpisode)
+		}
+	}
+}
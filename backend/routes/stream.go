@@ -1,19 +1,37 @@
-package routes
-
-import (
-	"stream4you/backend/controllers"
-	"stream4you/backend/middleware"
-
-	"github.com/gin-gonic/gin"
-)
-
-func SetupStreamRoutes(router *gin.RouterGroup) {
-	stream := router.Group("/stream", middleware.AuthMiddleware())
-	{
-		stream.GET("/:id", controllers.StreamVideo)
-		stream.GET("/:id/url", controllers.GetVideoURL)
-	}
-}
-
-
-
+package routes
+
+import (
+	"stream4you/backend/controllers"
+	"stream4you/backend/middleware"
+	"stream4you/backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupStreamRoutes(router *gin.RouterGroup) {
+	stream := router.Group("/stream")
+	{
+		stream.GET("/:id/url", middleware.AuthMiddleware(), controllers.GetVideoURL)
+		stream.GET("/episodes/:id/url", middleware.AuthMiddleware(), controllers.GetEpisodeVideoURL)
+		stream.GET("/sessions", middleware.AuthMiddleware(), controllers.GetPlaybackSessions)
+		stream.DELETE("/sessions/:sessionId", middleware.AuthMiddleware(), controllers.StopPlaybackSession)
+		stream.GET("/:id/thumbnails/:package/:file", middleware.OptionalAuthMiddleware(), controllers.StreamThumbnailFile)
+		stream.GET("/:id/extras/:extraId", middleware.OptionalAuthMiddleware(), controllers.StreamExtra)
+	}
+
+	// Signed URLs from the /url endpoints, usable without an Authorization header
+	movie := stream.Group("/:id/t/:token", middleware.StreamTokenMiddleware(utils.StreamKindMovie))
+	{
+		movie.GET("", controllers.StreamVideo)
+		movie.GET("/master.m3u8", controllers.StreamMasterPlaylist)
+		movie.GET("/manifest.mpd", controllers.StreamDASHManifest)
+		movie.GET("/hls/:package/*file", controllers.StreamPackageFile)
+		movie.GET("/dash/:package/*file", controllers.StreamPackageFile)
+		movie.GET("/subtitles/:file", controllers.StreamSubtitle)
+		movie.POST("/events", controllers.RecordPlaybackEvents)
+	}
+	stream.GET("/episodes/:id/t/:token", middleware.StreamTokenMiddleware(utils.StreamKindEpisode), controllers.StreamEpisode)
+}
+
+
+
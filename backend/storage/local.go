@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalStore keeps objects as files below one or more root directories.
+// Objects are written to and deleted from the first root only; the others
+// are read-only libraries. Reads look through the roots in order. Keys
+// resolving outside their root, e.g. through a symlink, are rejected with
+// ErrOutsideRoot.
+type LocalStore struct {
+	Roots []string
+}
+
+func NewLocalStore(roots ...string) *LocalStore {
+	return &LocalStore{Roots: roots}
+}
+
+// path maps a key to a file below one of the roots: the first root that
+// has the file, or the first root for objects that do not exist yet.
+func (s *LocalStore) path(key string) (string, error) {
+	if !ValidKey(key) || len(s.Roots) == 0 {
+		return "", ErrInvalidKey
+	}
+	for _, root := range s.Roots {
+		target := filepath.Join(root, filepath.FromSlash(key))
+		if _, err := os.Lstat(target); err == nil {
+			return target, confine(root, target)
+		}
+	}
+
+	return s.writePath(key)
+}
+
+// writePath maps a key to a file below the first root.
+func (s *LocalStore) writePath(key string) (string, error) {
+	if !ValidKey(key) || len(s.Roots) == 0 {
+		return "", ErrInvalidKey
+	}
+	target := filepath.Join(s.Roots[0], filepath.FromSlash(key))
+	if _, err := os.Lstat(target); err == nil {
+		return target, confine(s.Roots[0], target)
+	}
+	return target, confine(s.Roots[0], filepath.Dir(target))
+}
+
+// confine checks that target, with all symlinks resolved, is still inside
+// root. Parts of target that do not exist yet cannot be symlinks, so only
+// the existing prefix is resolved.
+func confine(root, target string) error {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if os.IsNotExist(err) {
+		// Nothing below a missing root can exist either
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resolvedRoot, err = filepath.Abs(resolvedRoot)
+	if err != nil {
+		return err
+	}
+
+	existing, rest := target, ""
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		// A dangling symlink: its target is unknown, treat it as escaping
+		return ErrOutsideRoot
+	}
+	resolved, err = filepath.Abs(filepath.Join(resolved, rest))
+	if err != nil {
+		return err
+	}
+	if resolved != resolvedRoot && !strings.HasPrefix(resolved, resolvedRoot+string(filepath.Separator)) {
+		return ErrOutsideRoot
+	}
+	return nil
+}
+
+func (s *LocalStore) OpenRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(target)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if length < 0 {
+		return file, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(file, length), file}, nil
+}
+
+func (s *LocalStore) Stat(ctx context.Context, key string) (Info, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return Info{}, err
+	}
+	stat, err := os.Stat(target)
+	if os.IsNotExist(err) {
+		return Info{}, ErrNotFound
+	}
+	if err != nil {
+		return Info{}, err
+	}
+	if stat.IsDir() {
+		return Info{}, ErrNotFound
+	}
+	return Info{Size: stat.Size(), ModTime: stat.ModTime()}, nil
+}
+
+// Put writes the object to a temporary file first and renames it into
+// place, so readers never see a partial object.
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader) error {
+	target, err := s.writePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := confine(s.Roots[0], filepath.Dir(target)); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	target, err := s.writePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// FilePath returns the file an existing object is stored in, for tools such
+// as ffmpeg that need a path.
+func (s *LocalStore) FilePath(key string) (string, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(target); os.IsNotExist(err) {
+		return "", ErrNotFound
+	}
+	return target, nil
+}
+
+// Presign is not supported: local files are always served by the API.
+func (s *LocalStore) Presign(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "", ErrPresignUnsupported
+}
+
+// move renames a local file into the store.
+func (s *LocalStore) move(path, key string) error {
+	target, err := s.writePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := confine(s.Roots[0], filepath.Dir(target)); err != nil {
+		return err
+	}
+	return os.Rename(path, target)
+}
+
+// PutFile stores the local file at path under key and removes the file.
+// A LocalStore moves the file when it is on the same file system instead
+// of copying it.
+func PutFile(ctx context.Context, store MediaStore, key, path string) error {
+	if local, ok := store.(*LocalStore); ok && local.move(path, key) == nil {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	err = store.Put(ctx, key, file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+}
+
+func TestLocalStorePath(t *testing.T) {
+	uploads := t.TempDir()
+	library := t.TempDir()
+	outside := t.TempDir()
+
+	writeTestFile(t, filepath.Join(uploads, "videos", "film.mp4"), "uploaded")
+	writeTestFile(t, filepath.Join(library, "Filme", "klassiker.mkv"), "library")
+	writeTestFile(t, filepath.Join(library, "videos", "film.mp4"), "shadowed")
+	writeTestFile(t, filepath.Join(outside, "secret.txt"), "secret")
+	symlink(t, outside, filepath.Join(uploads, "escape"))
+	symlink(t, filepath.Join(outside, "secret.txt"), filepath.Join(library, "secret.txt"))
+	symlink(t, filepath.Join(outside, "missing.mp4"), filepath.Join(uploads, "dangling.mp4"))
+	symlink(t, filepath.Join(library, "Filme"), filepath.Join(uploads, "filme"))
+	symlink(t, filepath.Join("..", "videos"), filepath.Join(uploads, "alias", "videos"))
+
+	store := NewLocalStore(uploads, library)
+	tests := []struct {
+		name    string
+		key     string
+		want    string // file the key maps to
+		wantErr error
+	}{
+		{name: "file in the first root", key: "videos/film.mp4", want: filepath.Join(uploads, "videos", "film.mp4")},
+		{name: "file under the second root", key: "Filme/klassiker.mkv", want: filepath.Join(library, "Filme", "klassiker.mkv")},
+		{name: "new file goes to the first root", key: "videos/neu.mp4", want: filepath.Join(uploads, "videos", "neu.mp4")},
+		{name: "symlink within the root", key: "alias/videos/film.mp4", want: filepath.Join(uploads, "alias", "videos", "film.mp4")},
+		{name: "parent directory", key: "../secret.txt", wantErr: ErrInvalidKey},
+		{name: "parent directory inside the key", key: "videos/../../secret.txt", wantErr: ErrInvalidKey},
+		{name: "absolute key", key: filepath.Join(outside, "secret.txt"), wantErr: ErrInvalidKey},
+		{name: "empty key", key: "", wantErr: ErrInvalidKey},
+		{name: "symlinked directory escaping the root", key: "escape/secret.txt", wantErr: ErrOutsideRoot},
+		{name: "new file below an escaping symlink", key: "escape/neu.mp4", wantErr: ErrOutsideRoot},
+		{name: "symlinked file escaping the second root", key: "secret.txt", wantErr: ErrOutsideRoot},
+		{name: "symlink into another root", key: "filme/klassiker.mkv", wantErr: ErrOutsideRoot},
+		{name: "dangling symlink", key: "dangling.mp4", wantErr: ErrOutsideRoot},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.path(tt.key)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("path(%q) error %v, want %v", tt.key, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("path(%q): %v", tt.key, err)
+			}
+			if got != tt.want {
+				t.Errorf("path(%q) = %s, want %s", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfine(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	symlink(t, outside, filepath.Join(root, "escape"))
+
+	tests := []struct {
+		name    string
+		root    string
+		target  string
+		wantErr bool
+	}{
+		{name: "root itself", root: root, target: root},
+		{name: "missing file", root: root, target: filepath.Join(root, "a", "b", "film.mp4")},
+		{name: "missing root", root: filepath.Join(root, "missing"), target: filepath.Join(root, "missing", "film.mp4")},
+		{name: "outside", root: root, target: filepath.Join(outside, "film.mp4"), wantErr: true},
+		{name: "sibling with the root as prefix", root: root, target: root + "-other/film.mp4", wantErr: true},
+		{name: "missing file below an escaping symlink", root: root, target: filepath.Join(root, "escape", "neu", "film.mp4"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := confine(tt.root, tt.target)
+			if tt.wantErr && !errors.Is(err, ErrOutsideRoot) {
+				t.Errorf("confine(%s) error %v, want ErrOutsideRoot", tt.target, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("confine(%s): %v", tt.target, err)
+			}
+		})
+	}
+}
+
+func TestLocalStoreRoots(t *testing.T) {
+	uploads := t.TempDir()
+	library := t.TempDir()
+	writeTestFile(t, filepath.Join(library, "Filme", "klassiker.mkv"), "library")
+	store := NewLocalStore(uploads, library)
+	ctx := context.Background()
+
+	info, err := store.Stat(ctx, "Filme/klassiker.mkv")
+	if err != nil || info.Size != int64(len("library")) {
+		t.Fatalf("Stat = %+v, %v", info, err)
+	}
+	reader, err := store.OpenRange(ctx, "Filme/klassiker.mkv", 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "bra" {
+		t.Errorf("OpenRange read %q, want %q", data, "bra")
+	}
+
+	// The second root is read-only: writes and deletes go to the first
+	if err := store.Put(ctx, "Filme/klassiker.mkv", strings.NewReader("upload")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "Filme/klassiker.mkv"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(uploads, "Filme", "klassiker.mkv")); !os.IsNotExist(err) {
+		t.Errorf("file in the first root was not deleted: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(library, "Filme", "klassiker.mkv")); err != nil || string(data) != "library" {
+		t.Errorf("file in the second root changed: %q, %v", data, err)
+	}
+
+	if _, err := store.Stat(ctx, "videos/missing.mp4"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat of a missing key: %v, want ErrNotFound", err)
+	}
+	if _, err := store.Stat(ctx, "Filme"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat of a directory: %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalStorePutRejectsEscapingDirectory(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	symlink(t, outside, filepath.Join(root, "videos"))
+	store := NewLocalStore(root)
+
+	err := store.Put(context.Background(), "videos/film.mp4", strings.NewReader("data"))
+	if !errors.Is(err, ErrOutsideRoot) {
+		t.Fatalf("Put error %v, want ErrOutsideRoot", err)
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("Put wrote %d files outside the root", len(entries))
+	}
+}
+
+func TestPutFileMovesIntoLocalStore(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(t.TempDir(), "upload.part")
+	writeTestFile(t, source, "video")
+	store := NewLocalStore(root)
+
+	if err := PutFile(context.Background(), store, "videos/film.mp4", source); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(source); !os.IsNotExist(err) {
+		t.Errorf("source still exists: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "videos", "film.mp4")); err != nil || string(data) != "video" {
+		t.Errorf("stored %q, %v", data, err)
+	}
+}
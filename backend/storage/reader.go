@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// readSeeker reads an object through OpenRange. Seeking only moves the
+// offset; the next Read opens a new range there. This lets
+// http.ServeContent answer range requests for any store.
+type readSeeker struct {
+	ctx    context.Context
+	store  MediaStore
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+// NewReadSeeker returns a reader for the object key of the given size.
+func NewReadSeeker(ctx context.Context, store MediaStore, key string, size int64) io.ReadSeekCloser {
+	return &readSeeker{ctx: ctx, store: store, key: key, size: size}
+}
+
+func (r *readSeeker) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.store.OpenRange(r.ctx, r.key, r.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *readSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	if offset != r.offset {
+		r.closeBody()
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+func (r *readSeeker) Close() error {
+	return r.closeBody()
+}
+
+func (r *readSeeker) closeBody() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
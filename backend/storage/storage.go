@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound           = errors.New("object not found")
+	ErrInvalidKey         = errors.New("invalid key")
+	ErrOutsideRoot        = errors.New("path resolves outside the media root")
+	ErrPresignUnsupported = errors.New("presigned URLs are not supported by this store")
+)
+
+type Info struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// MediaStore keeps media objects under slash-separated keys such as
+// "videos/….mp4" or "images/3f2a….webp".
+type MediaStore interface {
+	// OpenRange reads length bytes starting at offset. A negative length
+	// reads to the end of the object.
+	OpenRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (Info, error)
+	Put(ctx context.Context, key string, r io.Reader) error
+	Delete(ctx context.Context, key string) error
+	// Presign returns a URL clients can fetch the object from directly,
+	// or ErrPresignUnsupported.
+	Presign(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// ValidKey reports whether key is a clean relative key that stays inside
+// the store.
+func ValidKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, "/") && !strings.Contains(key, "\\") && path.Clean(key) == key &&
+		key != "." && key != ".." && !strings.HasPrefix(key, "../")
+}
+
+// MediaKey maps a stored media path to its key in a store with the given
+// local roots. Besides plain keys ("videos/film.mp4"), paths inside a root
+// are accepted: absolute paths below a root and paths starting with a
+// relative root or one of prefixes, e.g. "uploads/videos/film.mp4".
+// Anything else, including paths with "..", is rejected.
+func MediaKey(mediaPath string, roots []string, prefixes ...string) (string, bool) {
+	if filepath.IsAbs(mediaPath) {
+		for _, root := range roots {
+			root, err := filepath.Abs(root)
+			if err != nil {
+				continue
+			}
+			if rel, err := filepath.Rel(root, filepath.Clean(mediaPath)); err == nil && ValidKey(filepath.ToSlash(rel)) {
+				return filepath.ToSlash(rel), true
+			}
+		}
+		return "", false
+	}
+
+	key := strings.TrimPrefix(filepath.ToSlash(mediaPath), "./")
+	for _, root := range append(append([]string{}, prefixes...), roots...) {
+		root = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(root)), "./")
+		if !filepath.IsAbs(root) && strings.HasPrefix(key, root+"/") {
+			key = strings.TrimPrefix(key, root+"/")
+			break
+		}
+	}
+	return key, ValidKey(key)
+}
+
+// Exists reports whether key is stored.
+func Exists(ctx context.Context, store MediaStore, key string) bool {
+	_, err := store.Stat(ctx, key)
+	return err == nil
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidKey(t *testing.T) {
+	tests := map[string]bool{
+		"videos/film.mp4":        true,
+		"images/3f2a.webp":       true,
+		"film.mp4":               true,
+		"..film.mp4":             true,
+		"":                       false,
+		".":                      false,
+		"..":                     false,
+		"../film.mp4":            false,
+		"videos/../../film.mp4":  false,
+		"videos/../film.mp4":     false,
+		"./videos/film.mp4":      false,
+		"videos//film.mp4":       false,
+		"videos/":                false,
+		"/etc/passwd":            false,
+		"videos\\..\\secret.txt": false,
+	}
+	for key, want := range tests {
+		if got := ValidKey(key); got != want {
+			t.Errorf("ValidKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestMediaKey(t *testing.T) {
+	uploads := t.TempDir()
+	library := t.TempDir()
+	outside := t.TempDir()
+
+	// A relative root, as in the default configuration
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	relative, err := filepath.Rel(wd, filepath.Join(t.TempDir(), "media"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := []string{uploads, library, relative}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain key", path: "videos/film.mp4", want: "videos/film.mp4"},
+		{name: "legacy uploads prefix", path: "uploads/videos/film.mp4", want: "videos/film.mp4"},
+		{name: "legacy prefix with dot", path: "./uploads/videos/film.mp4", want: "videos/film.mp4"},
+		{name: "relative root prefix", path: filepath.Join(relative, "videos", "film.mp4"), want: "videos/film.mp4"},
+		{name: "absolute path in the first root", path: filepath.Join(uploads, "videos", "film.mp4"), want: "videos/film.mp4"},
+		{name: "absolute path in the second root", path: filepath.Join(library, "Filme", "film.mkv"), want: "Filme/film.mkv"},
+		{name: "absolute path in a relative root", path: filepath.Join(wd, relative, "film.mp4"), want: "film.mp4"},
+		{name: "absolute path cleaned into a root", path: uploads + "/videos/../film.mp4", want: "film.mp4"},
+		{name: "absolute path outside every root", path: filepath.Join(outside, "film.mp4"), wantErr: true},
+		{name: "absolute path escaping a root", path: uploads + "/../film.mp4", wantErr: true},
+		{name: "root itself", path: uploads, wantErr: true},
+		{name: "parent directory", path: "../film.mp4", wantErr: true},
+		{name: "parent directory after a prefix", path: "uploads/../film.mp4", wantErr: true},
+		{name: "parent directory inside a key", path: "videos/../../film.mp4", wantErr: true},
+		{name: "URL", path: "https://cdn.example.com/film.mp4", wantErr: true},
+		{name: "empty", path: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := MediaKey(tt.path, roots, "uploads")
+			if ok == tt.wantErr {
+				t.Fatalf("MediaKey(%q) = %q, %v", tt.path, key, ok)
+			}
+			if ok && key != tt.want {
+				t.Errorf("MediaKey(%q) = %q, want %q", tt.path, key, tt.want)
+			}
+		})
+	}
+}
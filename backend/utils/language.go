@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NegotiateLanguage picks the response language. An explicit lang query
+// value wins; otherwise the Accept-Language header is matched by quality,
+// comparing primary tags only ("en-US" matches "en"). fallback is returned
+// when nothing matches.
+func NegotiateLanguage(query, acceptLanguage string, supported []string, fallback string) string {
+	isSupported := func(tag string) (string, bool) {
+		primary := strings.ToLower(strings.SplitN(strings.TrimSpace(tag), "-", 2)[0])
+		for _, language := range supported {
+			if strings.EqualFold(language, primary) {
+				return language, true
+			}
+		}
+		return "", false
+	}
+
+	if query != "" {
+		if language, ok := isSupported(query); ok {
+			return language
+		}
+	}
+
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+	var candidates []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			candidates = append(candidates, weighted{tag, quality})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	for _, candidate := range candidates {
+		if language, ok := isSupported(candidate.tag); ok {
+			return language
+		}
+	}
+	return fallback
+}
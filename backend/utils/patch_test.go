@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func decodeJSON(t *testing.T, data string) interface{} {
+	t.Helper()
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	return value
+}
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7396, appendix A
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, test := range tests {
+		target := decodeJSON(t, test.target)
+		got := MergePatch(target, decodeJSON(t, test.patch))
+		if !reflect.DeepEqual(got, decodeJSON(t, test.want)) {
+			t.Errorf("MergePatch(%s, %s) = %v, want %s", test.target, test.patch, got, test.want)
+		}
+		if !reflect.DeepEqual(target, decodeJSON(t, test.target)) {
+			t.Errorf("MergePatch(%s, %s) modified the target", test.target, test.patch)
+		}
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	// Mostly examples from RFC 6902, appendix A
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add object member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"append to array", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{"remove object member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"replace with null", `{"baz":"qux"}`, `[{"op":"replace","path":"/baz","value":null}]`, `{"baz":null}`},
+		{"replace document", `{"foo":"bar"}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+		{"move", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"copy", `{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`, `{"foo":{"bar":1},"baz":{"bar":2}}`},
+		{"test number", `{"foo":10}`, `[{"op":"test","path":"/foo","value":10.0}]`, `{"foo":10}`},
+		{"test null", `{"foo":null}`, `[{"op":"test","path":"/foo","value":null}]`, `{"foo":null}`},
+		{"escaped pointer", `{"a/b":1,"m~n":2}`, `[{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/m~0n"}]`, `{}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var operations []JSONPatchOperation
+			if err := json.Unmarshal([]byte(test.patch), &operations); err != nil {
+				t.Fatal(err)
+			}
+			doc := decodeJSON(t, test.doc)
+			got, err := ApplyJSONPatch(doc, operations)
+			if err != nil {
+				t.Fatalf("ApplyJSONPatch: %v", err)
+			}
+			if !jsonValuesEqual(got, decodeJSON(t, test.want)) {
+				t.Errorf("got %v, want %s", got, test.want)
+			}
+			if !reflect.DeepEqual(doc, decodeJSON(t, test.doc)) {
+				t.Error("the document was modified")
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchErrors(t *testing.T) {
+	tests := []struct {
+		name, doc, patch string
+	}{
+		{"missing value", `{"foo":1}`, `[{"op":"add","path":"/bar"}]`},
+		{"missing member", `{"foo":1}`, `[{"op":"remove","path":"/bar"}]`},
+		{"missing parent", `{"foo":1}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`},
+		{"index out of range", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"qux"}]`},
+		{"leading zero", `{"foo":["bar","baz"]}`, `[{"op":"remove","path":"/foo/01"}]`},
+		{"move into itself", `{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`},
+		{"invalid pointer", `{"foo":1}`, `[{"op":"remove","path":"foo"}]`},
+		{"unknown operation", `{"foo":1}`, `[{"op":"frobnicate","path":"/foo"}]`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var operations []JSONPatchOperation
+			if err := json.Unmarshal([]byte(test.patch), &operations); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := ApplyJSONPatch(decodeJSON(t, test.doc), operations); err == nil {
+				t.Errorf("expected an error, got %v", got)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchIsAtomic(t *testing.T) {
+	var operations []JSONPatchOperation
+	json.Unmarshal([]byte(`[{"op":"replace","path":"/baz","value":"boo"},{"op":"test","path":"/foo","value":"other"}]`), &operations)
+	doc := decodeJSON(t, `{"baz":"qux","foo":"bar"}`)
+
+	got, err := ApplyJSONPatch(doc, operations)
+	if !errors.Is(err, ErrJSONPatchTestFailed) {
+		t.Fatalf("got error %v, want ErrJSONPatchTestFailed", err)
+	}
+	if got != nil {
+		t.Errorf("got partial result %v", got)
+	}
+	if !reflect.DeepEqual(doc, decodeJSON(t, `{"baz":"qux","foo":"bar"}`)) {
+		t.Error("the document was modified")
+	}
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"stream4you/backend/config"
+)
+
+// Kinds of media a stream token grants access to
+const (
+	StreamKindMovie   = "movie"
+	StreamKindEpisode = "episode"
+)
+
+var (
+	ErrInvalidStreamToken = errors.New("invalid stream token")
+	ErrStreamTokenExpired = errors.New("stream token expired")
+)
+
+// StreamClaims is what a signed stream URL grants: one user may stream one
+// movie or episode in a playback session until ExpiresAt. With an IP, only
+// requests from that address are accepted.
+type StreamClaims struct {
+	Kind      string
+	ID        string
+	UserID    string
+	Role      string
+	SessionID string
+	ExpiresAt time.Time
+	IP        string
+}
+
+// GenerateStreamToken signs claims into a token for a stream URL. The token
+// is URL-safe and goes into the path, so relative playlist and segment URIs
+// inherit it. The IP is only part of the signature, not of the token.
+func GenerateStreamToken(claims StreamClaims) string {
+	payload := streamPayload(claims, claims.IP != "")
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(streamSignature(payload, claims.IP))
+}
+
+// ValidateStreamToken checks a token's signature and expiry and returns its
+// claims. clientIP is the address of the request, used for tokens bound to
+// an IP.
+func ValidateStreamToken(token, clientIP string) (*StreamClaims, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidStreamToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidStreamToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, ErrInvalidStreamToken
+	}
+
+	fields := strings.Split(string(payload), "|")
+	if len(fields) != 7 {
+		return nil, ErrInvalidStreamToken
+	}
+	claims := &StreamClaims{Kind: fields[0], ID: fields[1], UserID: fields[2], Role: fields[3], SessionID: fields[4]}
+	expiresAt, err := strconv.ParseInt(fields[5], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidStreamToken
+	}
+	claims.ExpiresAt = time.Unix(expiresAt, 0)
+	if fields[6] == "ip" {
+		claims.IP = clientIP
+	}
+
+	if !hmac.Equal(signature, streamSignature(string(payload), claims.IP)) {
+		return nil, ErrInvalidStreamToken
+	}
+	if time.Now().After(claims.ExpiresAt) {
+		return nil, ErrStreamTokenExpired
+	}
+	return claims, nil
+}
+
+func streamPayload(claims StreamClaims, bindIP bool) string {
+	ipFlag := ""
+	if bindIP {
+		ipFlag = "ip"
+	}
+	return strings.Join([]string{
+		claims.Kind, claims.ID, claims.UserID, claims.Role, claims.SessionID,
+		strconv.FormatInt(claims.ExpiresAt.Unix(), 10), ipFlag,
+	}, "|")
+}
+
+// streamSignature is an HMAC-SHA256 over the payload and the bound IP. The
+// key falls back to the JWT secret, prefixed so a stream signature can never
+// pass as a JWT signature.
+func streamSignature(payload, ip string) []byte {
+	key := config.AppConfig.StreamURLSecret
+	if key == "" {
+		key = "stream-url:" + config.AppConfig.JWTSecret
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(payload + "|" + ip))
+	return mac.Sum(nil)
+}